@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	wstools "github.com/XDoubleU/essentia/pkg/communication/ws"
 	"github.com/XDoubleU/essentia/pkg/validate"
@@ -33,11 +34,17 @@ func (app *application) websocketRoutes(mux *http.ServeMux) {
 }
 
 func (app *application) getWebSocketHandler() http.HandlerFunc {
+	originPatterns := []string{}
+	for _, origin := range app.config.AllowedOrigins {
+		_, host, _ := strings.Cut(origin, "://")
+		originPatterns = append(originPatterns, host)
+	}
 
 	wsHandler := wstools.CreateWebSocketHandler[SubscribeMessageDto](
 		app.logger,
 		1,
 		10,
+		wstools.WithOriginPatterns(originPatterns...),
 	)
 	wsHandler.AddTopic(
 		"topic",
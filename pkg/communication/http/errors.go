@@ -1,7 +1,6 @@
 package http
 
 import (
-	"errors"
 	"net/http"
 
 	"github.com/XDoubleU/essentia/pkg/context"
@@ -9,39 +8,27 @@ import (
 	"github.com/XDoubleU/essentia/pkg/logging"
 )
 
-// HandleError is used to translate errors to the right HTTP response.
+// HandleError is used to translate errors to the right HTTP response,
+// using [errortools.ToErrorDto].
 func HandleError(
 	w http.ResponseWriter,
 	r *http.Request,
 	err error,
 ) {
-	//nolint:exhaustruct //fields are not needed here
-	notFoundError := errortools.NotFoundError{}
-	//nolint:exhaustruct //fields are not needed here
-	conflictError := errortools.ConflictError{}
-
-	badRequestError := errortools.BadRequestError{}
-
-	unauthorizedError := errortools.UnauthorizedError{}
-
-	switch {
-	case errors.As(err, &unauthorizedError):
-		UnauthorizedResponse(w, r, unauthorizedError)
-	case errors.As(err, &badRequestError):
-		BadRequestResponse(w, r, badRequestError)
-	case errors.As(err, &notFoundError):
-		NotFoundResponse(w, r, notFoundError)
-	case errors.As(err, &conflictError):
-		ConflictResponse(w, r, conflictError)
-	default:
+	errorDto, ok := errortools.ToErrorDto(err)
+	if !ok {
 		ServerErrorResponse(w, r, err)
+		return
 	}
+
+	ErrorResponse(w, r, errorDto.Status, errorDto.Message)
 }
 
 // ErrorResponse is used to handle any kind of error.
 func ErrorResponse(w http.ResponseWriter, r *http.Request,
 	status int, message any) {
 	errorDto := errortools.NewErrorDto(status, message)
+	errorDto.RequestID = context.RequestID(r.Context())
 	err := WriteJSON(w, status, errorDto, nil)
 	if err != nil {
 		context.Logger(r.Context()).
@@ -74,6 +61,24 @@ func RateLimitExceededResponse(w http.ResponseWriter,
 	ErrorResponse(w, r, http.StatusTooManyRequests, errortools.MessageTooManyRequests)
 }
 
+// ServiceUnavailableResponse is used to handle an error when the server
+// is temporarily unable to handle a request, for example when overloaded.
+func ServiceUnavailableResponse(w http.ResponseWriter, r *http.Request) {
+	ErrorResponse(
+		w,
+		r,
+		http.StatusServiceUnavailable,
+		errortools.MessageServiceUnavailable,
+	)
+}
+
+// TimeoutResponse is used to handle an error when a request took too long,
+// answering with status, which is either [http.StatusServiceUnavailable]
+// or [http.StatusGatewayTimeout].
+func TimeoutResponse(w http.ResponseWriter, r *http.Request, status int) {
+	ErrorResponse(w, r, status, errortools.MessageTimeout)
+}
+
 // UnauthorizedResponse is used to handle an error when a user
 // isn't authorized.
 func UnauthorizedResponse(w http.ResponseWriter,
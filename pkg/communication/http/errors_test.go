@@ -97,6 +97,28 @@ func TestRateLimitExceededResponse(t *testing.T) {
 	assert.Equal(t, errortools.MessageTooManyRequests, errorDto.Message)
 }
 
+func TestServiceUnavailableResponse(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		httptools.ServiceUnavailableResponse(w, r)
+	}
+
+	statusCode, errorDto := testError(t, handler)
+
+	assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+	assert.Equal(t, errortools.MessageServiceUnavailable, errorDto.Message)
+}
+
+func TestTimeoutResponse(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		httptools.TimeoutResponse(w, r, http.StatusGatewayTimeout)
+	}
+
+	statusCode, errorDto := testError(t, handler)
+
+	assert.Equal(t, http.StatusGatewayTimeout, statusCode)
+	assert.Equal(t, errortools.MessageTimeout, errorDto.Message)
+}
+
 func TestUnauthorizedResponse(t *testing.T) {
 	handler := func(w http.ResponseWriter, r *http.Request) {
 		httptools.HandleError(
@@ -171,3 +193,18 @@ func TestFailedValidationResponse(t *testing.T) {
 		"field": "invalid value",
 	}, errorDto.Message)
 }
+
+func TestHandleFailedValidationError(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		httptools.HandleError(w, r, errortools.NewFailedValidationError(
+			map[string]string{"field": "invalid value"},
+		))
+	}
+
+	statusCode, errorDto := testError(t, handler)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, statusCode)
+	assert.Equal(t, map[string]any{
+		"field": "invalid value",
+	}, errorDto.Message)
+}
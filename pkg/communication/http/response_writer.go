@@ -9,18 +9,21 @@ import (
 	"net/http"
 )
 
-// A ResponseWriter is used to capture set status codes.
+// A ResponseWriter is used to capture set status
+// codes and the amount of bytes written.
 type ResponseWriter interface {
 	http.ResponseWriter
 	http.Hijacker // need this for sentry
 	http.Flusher  // need this for sentry
 	io.ReaderFrom // need this for sentry
 	Status() int
+	BytesWritten() int64
 }
 
 type responseWriter struct {
 	http.ResponseWriter
-	status int
+	status       int
+	bytesWritten int64
 }
 
 // Flush sends any buffered data to the client.
@@ -42,12 +45,34 @@ func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
 		panic(fmt.Errorf("ResponseWriter doesn't implement io.ReaderFrom"))
 	}
 
-	return reader.ReadFrom(r)
+	if w.status == -1 {
+		w.status = http.StatusOK
+	}
+
+	n, err := reader.ReadFrom(r)
+	w.bytesWritten += n
+	return n, err
 }
 
 // NewResponseWriter returns a new [ResponseWriter].
 func NewResponseWriter(w http.ResponseWriter) ResponseWriter {
-	return &responseWriter{w, -1}
+	return &responseWriter{
+		ResponseWriter: w,
+		status:         -1,
+		bytesWritten:   0,
+	}
+}
+
+// Write writes data to the connection as part of an HTTP reply,
+// implicitly setting the status to 200 if it wasn't set yet.
+func (w *responseWriter) Write(data []byte) (int, error) {
+	if w.status == -1 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(data)
+	w.bytesWritten += int64(n)
+	return n, err
 }
 
 // WriteHeader sets the internal status value of a [ResponseWriter].
@@ -64,6 +89,18 @@ func (w responseWriter) Status() int {
 	return w.status
 }
 
+// BytesWritten returns the amount of bytes of
+// the body written by a [ResponseWriter].
+func (w responseWriter) BytesWritten() int64 {
+	return w.bytesWritten
+}
+
+// Unwrap returns the underlying [http.ResponseWriter],
+// as used by [http.ResponseController].
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // Hijack lets the caller take over the connection.
 // After a call to Hijack the HTTP server library
 // will not do anything else with the connection.
@@ -15,3 +15,14 @@ func TestStatus(t *testing.T) {
 	rw.WriteHeader(http.StatusOK)
 	assert.Equal(t, http.StatusOK, rw.Status())
 }
+
+func TestBytesWritten(t *testing.T) {
+	res := httptest.NewRecorder()
+	rw := httptools.NewResponseWriter(res)
+
+	_, _ = rw.Write([]byte("test"))
+	_, _ = rw.Write([]byte("test"))
+
+	assert.Equal(t, http.StatusOK, rw.Status())
+	assert.Equal(t, int64(8), rw.BytesWritten())
+}
@@ -0,0 +1,63 @@
+package ws
+
+import (
+	"context"
+	"sync"
+)
+
+// BrokerHandler is called by a [Broker] for
+// every payload published to one of the topics.
+type BrokerHandler = func(topic string, payload []byte)
+
+// Broker is used to distribute events of [Topic]s between
+// multiple instances of an application.
+// A Postgres implementation is provided by [postgres.NewBroker].
+type Broker interface {
+	// Publish sends a payload of a topic to all instances,
+	// including the publishing instance.
+	Publish(ctx context.Context, topic string, payload []byte) error
+	// Listen registers a [BrokerHandler] which is called
+	// for every payload published by any instance.
+	// Handlers can block, for example when the queue of a [Topic] is full,
+	// which shouldn't stop an implementation from receiving payloads.
+	Listen(handler BrokerHandler)
+}
+
+// MemoryBroker is a [Broker] which only distributes
+// payloads within the current process.
+type MemoryBroker struct {
+	handlers   []BrokerHandler
+	handlersMu *sync.RWMutex
+}
+
+// NewMemoryBroker creates a new [MemoryBroker].
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		handlers:   []BrokerHandler{},
+		handlersMu: &sync.RWMutex{},
+	}
+}
+
+// Publish passes the payload to all registered [BrokerHandler]s.
+func (b *MemoryBroker) Publish(
+	_ context.Context,
+	topic string,
+	payload []byte,
+) error {
+	b.handlersMu.RLock()
+	defer b.handlersMu.RUnlock()
+
+	for _, handler := range b.handlers {
+		handler(topic, payload)
+	}
+
+	return nil
+}
+
+// Listen registers a [BrokerHandler].
+func (b *MemoryBroker) Listen(handler BrokerHandler) {
+	b.handlersMu.Lock()
+	defer b.handlersMu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
@@ -0,0 +1,59 @@
+package ws_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	wstools "github.com/XDoubleU/essentia/pkg/communication/ws"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBrokerInstance(
+	t *testing.T,
+	broker wstools.Broker,
+) (*wstools.Topic, *httptest.Server) {
+	t.Helper()
+
+	ws := wstools.CreateWebSocketHandler[TestSubscribeMsg](
+		logging.NewNopLogger(),
+		1,
+		10,
+	)
+
+	topic, err := ws.AddTopic(
+		"exists",
+		[]string{"http://localhost"},
+		func(_ context.Context, _ *wstools.Topic) (any, error) {
+			return TestResponse{Ok: false}, nil
+		},
+	)
+	require.Nil(t, err)
+
+	ws.SetBroker(broker)
+
+	ts := httptest.NewServer(ws.Handler())
+	t.Cleanup(ts.Close)
+
+	return topic, ts
+}
+
+func TestBrokerFanOut(t *testing.T) {
+	broker := wstools.NewMemoryBroker()
+
+	topicA, tsA := setupBrokerInstance(t, broker)
+	_, tsB := setupBrokerInstance(t, broker)
+
+	connA := dialAndSubscribe(t, tsA, TestSubscribeMsg{TopicName: "exists"})
+	connB := dialAndSubscribe(t, tsB, TestSubscribeMsg{TopicName: "exists"})
+
+	assert.False(t, readMessage[TestResponse](t, connA).Ok)
+	assert.False(t, readMessage[TestResponse](t, connB).Ok)
+
+	topicA.EnqueueEvent(TestResponse{Ok: true})
+
+	assert.True(t, readMessage[TestResponse](t, connA).Ok)
+	assert.True(t, readMessage[TestResponse](t, connB).Ok)
+}
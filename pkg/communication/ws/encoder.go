@@ -0,0 +1,194 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/coder/websocket"
+)
+
+// Encoder is used by a [Topic] to encode each event once,
+// after which the result is sent to all [Subscriber]s.
+type Encoder interface {
+	Encode(event any) ([]byte, error)
+	MessageType() websocket.MessageType
+}
+
+// JSONEncoder encodes events as JSON in text frames.
+// This is the default [Encoder] of a [Topic].
+type JSONEncoder struct{}
+
+// Encode encodes an event as JSON.
+func (JSONEncoder) Encode(event any) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+// MessageType returns [websocket.MessageText].
+func (JSONEncoder) MessageType() websocket.MessageType {
+	return websocket.MessageText
+}
+
+// RawEncoder sends events, which should be []byte,
+// as they are in binary frames.
+// As there is no envelope to store a sequence in,
+// this can't be combined with [Topic.EnableHistory].
+type RawEncoder struct{}
+
+// Encode returns the event as is.
+func (RawEncoder) Encode(event any) ([]byte, error) {
+	switch event := event.(type) {
+	case []byte:
+		return event, nil
+	case json.RawMessage:
+		return event, nil
+	default:
+		return nil, fmt.Errorf("can't send event of type %T as raw frame", event)
+	}
+}
+
+// MessageType returns [websocket.MessageBinary].
+func (RawEncoder) MessageType() websocket.MessageType {
+	return websocket.MessageBinary
+}
+
+// MessagePackEncoder encodes events as MessagePack in binary frames.
+// Events are converted the same way as for JSON, so json tags are respected.
+type MessagePackEncoder struct{}
+
+// Encode encodes an event as MessagePack.
+func (MessagePackEncoder) Encode(event any) ([]byte, error) {
+	js, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(js))
+	decoder.UseNumber()
+
+	var value any
+	err = decoder.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = writeMessagePack(&buf, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// MessageType returns [websocket.MessageBinary].
+func (MessagePackEncoder) MessageType() websocket.MessageType {
+	return websocket.MessageBinary
+}
+
+//nolint:mnd //MessagePack format bytes
+func writeMessagePack(buf *bytes.Buffer, value any) error {
+	switch value := value.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if value {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		return writeMessagePackNumber(buf, value)
+	case string:
+		writeMessagePackHeader(buf, len(value), 0xa0, 31, 0xd9, 0xda, 0xdb)
+		buf.WriteString(value)
+	case []any:
+		writeMessagePackHeader(buf, len(value), 0x90, 15, 0, 0xdc, 0xdd)
+		for _, item := range value {
+			err := writeMessagePack(buf, item)
+			if err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		writeMessagePackHeader(buf, len(value), 0x80, 15, 0, 0xde, 0xdf)
+
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			_ = writeMessagePack(buf, key)
+			err := writeMessagePack(buf, value[key])
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("can't encode value of type %T as MessagePack", value)
+	}
+
+	return nil
+}
+
+//nolint:mnd //MessagePack format bytes
+func writeMessagePackNumber(buf *bytes.Buffer, number json.Number) error {
+	integer, err := number.Int64()
+	if err == nil {
+		if integer >= -32 && integer <= 127 {
+			buf.WriteByte(byte(integer))
+			return nil
+		}
+
+		buf.WriteByte(0xd3)
+		//nolint:gosec //two's complement is intended
+		return binary.Write(buf, binary.BigEndian, uint64(integer))
+	}
+
+	float, err := number.Float64()
+	if err != nil {
+		return errors.New("invalid number")
+	}
+
+	buf.WriteByte(0xcb)
+	return binary.Write(buf, binary.BigEndian, math.Float64bits(float))
+}
+
+// writeMessagePackHeader writes the header of a
+// string, array or map of the provided length.
+// A fixPrefix is used for lengths up to fixMax,
+// the 8-bit format is skipped when it doesn't exist.
+//
+//nolint:mnd //MessagePack format bytes
+func writeMessagePackHeader(
+	buf *bytes.Buffer,
+	length int,
+	fixPrefix byte,
+	fixMax int,
+	prefix8 byte,
+	prefix16 byte,
+	prefix32 byte,
+) {
+	switch {
+	case length <= fixMax:
+		//nolint:gosec //length is at most fixMax
+		buf.WriteByte(fixPrefix | byte(length))
+	case prefix8 != 0 && length <= math.MaxUint8:
+		//nolint:gosec //length fits in a byte
+		buf.Write([]byte{prefix8, byte(length)})
+	case length <= math.MaxUint16:
+		buf.WriteByte(prefix16)
+		//nolint:gosec //length fits in 16 bits
+		_ = binary.Write(buf, binary.BigEndian, uint16(length))
+	default:
+		buf.WriteByte(prefix32)
+		//nolint:gosec //websocket messages aren't larger than 4GB
+		_ = binary.Write(buf, binary.BigEndian, uint32(length))
+	}
+}
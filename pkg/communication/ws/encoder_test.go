@@ -0,0 +1,198 @@
+package ws_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	wstools "github.com/XDoubleU/essentia/pkg/communication/ws"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type benchmarkEvent struct {
+	ID        int               `json:"id"`
+	Name      string            `json:"name"`
+	Tags      []string          `json:"tags"`
+	Values    []float64         `json:"values"`
+	Metadata  map[string]string `json:"metadata"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+func newBenchmarkEvent() benchmarkEvent {
+	return benchmarkEvent{
+		ID:     1,
+		Name:   "benchmark",
+		Tags:   []string{"a", "b", "c", "d"},
+		Values: []float64{1.5, 2.5, 3.5, 4.5, 5.5, 6.5, 7.5, 8.5},
+		Metadata: map[string]string{
+			"source": "benchmark",
+			"region": "europe",
+			"owner":  "essentia",
+		},
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestMessagePackEncoder(t *testing.T) {
+	data, err := wstools.MessagePackEncoder{}.Encode(map[string]any{
+		"b": []any{1, -1, "x"},
+		"a": true,
+		"c": 1.5,
+		"d": nil,
+	})
+	require.Nil(t, err)
+
+	expected := []byte{
+		0x84,
+		0xa1, 'a', 0xc3,
+		0xa1, 'b', 0x93, 0x01, 0xff, 0xa1, 'x',
+		0xa1, 'c', 0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0,
+		0xa1, 'd', 0xc0,
+	}
+	assert.Equal(t, expected, data)
+	assert.Equal(
+		t,
+		websocket.MessageBinary,
+		wstools.MessagePackEncoder{}.MessageType(),
+	)
+}
+
+func TestRawEncoder(t *testing.T) {
+	ws := wstools.CreateWebSocketHandler[TestSubscribeMsg](
+		logging.NewNopLogger(),
+		1,
+		10,
+	)
+
+	topic, err := ws.AddTopic(
+		"raw",
+		[]string{},
+		func(_ context.Context, _ *wstools.Topic) (any, error) {
+			return []byte{0}, nil
+		},
+	)
+	require.Nil(t, err)
+	topic.SetEncoder(wstools.RawEncoder{})
+
+	ts := httptest.NewServer(ws.Handler())
+	t.Cleanup(ts.Close)
+
+	conn := dialAndSubscribe(t, ts, TestSubscribeMsg{TopicName: "raw"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msgType, data, err := conn.Read(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, websocket.MessageBinary, msgType)
+	assert.Equal(t, []byte{0}, data)
+
+	topic.EnqueueEvent([]byte{1, 2, 3})
+
+	msgType, data, err = conn.Read(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, websocket.MessageBinary, msgType)
+	assert.Equal(t, []byte{1, 2, 3}, data)
+}
+
+// setupBenchmark subscribes amount [wstools.Subscriber]s using
+// a single connection, the client side of which is returned.
+func setupBenchmark(
+	b *testing.B,
+	amount int,
+) (*wstools.Topic, *websocket.Conn, *websocket.Conn) {
+	b.Helper()
+
+	topic := wstools.NewTopic(
+		logging.NewNopLogger(),
+		"benchmark",
+		[]string{},
+		1,
+		amount,
+		nil,
+	)
+
+	serverConns := make(chan *websocket.Conn, 1)
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			conn, err := websocket.Accept(w, r, nil)
+			if err != nil {
+				return
+			}
+
+			ctx := conn.CloseRead(r.Context())
+			serverConns <- conn
+			<-ctx.Done()
+		},
+	))
+	b.Cleanup(ts.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+	clientConn, _, err := websocket.Dial(ctx, wsURL, nil)
+	require.Nil(b, err)
+	b.Cleanup(func() { _ = clientConn.CloseNow() })
+
+	serverConn := <-serverConns
+	for range amount {
+		err = topic.Subscribe(serverConn)
+		require.Nil(b, err)
+	}
+
+	return topic, serverConn, clientConn
+}
+
+func readAmount(b *testing.B, conn *websocket.Conn, amount int) {
+	b.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for range amount {
+		_, _, err := conn.Read(ctx)
+		require.Nil(b, err)
+	}
+}
+
+func BenchmarkTopicEvent(b *testing.B) {
+	for _, amount := range []int{1_000, 10_000} {
+		event := newBenchmarkEvent()
+
+		name := fmt.Sprintf("encode-per-subscriber-%d", amount)
+		b.Run(name, func(b *testing.B) {
+			_, serverConn, clientConn := setupBenchmark(b, amount)
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for range b.N {
+				go func() {
+					for range amount {
+						_ = wsjson.Write(ctx, serverConn, event)
+					}
+				}()
+
+				readAmount(b, clientConn, amount)
+			}
+		})
+
+		b.Run(fmt.Sprintf("encode-once-%d", amount), func(b *testing.B) {
+			topic, _, clientConn := setupBenchmark(b, amount)
+
+			b.ResetTimer()
+			for range b.N {
+				topic.EnqueueEvent(event)
+				readAmount(b, clientConn, amount)
+			}
+		})
+	}
+}
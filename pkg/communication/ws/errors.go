@@ -14,6 +14,17 @@ import (
 	"github.com/coder/websocket/wsjson"
 )
 
+// ErrorMessageType is the type of [ErrorMessageDto]s,
+// so clients can tell errors and events apart.
+const ErrorMessageType = "error"
+
+// ErrorMessageDto is sent when an error occurred on a WebSocket.
+// Its Type is always [ErrorMessageType].
+type ErrorMessageDto struct {
+	Type string `json:"type"`
+	errortools.ErrorDto
+} //	@name	ErrorMessageDto
+
 // ErrorResponse is used to handle any kind of error that occurred on a WebSocket.
 func ErrorResponse(
 	ctx context.Context,
@@ -21,8 +32,10 @@ func ErrorResponse(
 	status int,
 	message any,
 ) {
-	errorDto := errortools.NewErrorDto(status, message)
-	err := wsjson.Write(ctx, conn, errorDto)
+	err := wsjson.Write(ctx, conn, ErrorMessageDto{
+		Type:     ErrorMessageType,
+		ErrorDto: newErrorDto(ctx, status, message),
+	})
 	if err != nil {
 		contexttools.Logger(ctx).
 			ErrorContext(ctx, "failed to write JSON", logging.ErrAttr(err))
@@ -74,6 +87,37 @@ func ForbiddenResponse(ctx context.Context, conn *websocket.Conn) {
 	ErrorResponse(ctx, conn, http.StatusForbidden, errortools.MessageForbidden)
 }
 
+// errorToErrorDto is used to translate errors to the right
+// [errortools.ErrorDto], using [errortools.ToErrorDto].
+func errorToErrorDto(ctx context.Context, err error) errortools.ErrorDto {
+	errorDto, ok := errortools.ToErrorDto(err)
+	if ok {
+		return newErrorDto(ctx, errorDto.Status, errorDto.Message)
+	}
+
+	contexttools.Logger(ctx).
+		ErrorContext(ctx, "server error occurred", logging.ErrAttr(err))
+
+	message := errortools.MessageInternalServerError
+	if contexttools.ShowErrors(ctx) {
+		message = err.Error()
+	}
+
+	return newErrorDto(ctx, http.StatusInternalServerError, message)
+}
+
+// newErrorDto creates a new [errortools.ErrorDto]
+// containing the ID of the request stored in the context.
+func newErrorDto(
+	ctx context.Context,
+	status int,
+	message any,
+) errortools.ErrorDto {
+	errorDto := errortools.NewErrorDto(status, message)
+	errorDto.RequestID = contexttools.RequestID(ctx)
+	return errorDto
+}
+
 func isWSProtocolViolation(err error) bool {
 	return strings.Contains(err.Error(), "WebSocket protocol violation")
 }
@@ -0,0 +1,31 @@
+package ws
+
+import (
+	"context"
+
+	"github.com/coder/websocket"
+)
+
+// SubscribeAs subscribes conn to topic as a member with identity, without
+// the read loop of a [WebSocketHandler] unsubscribing it when it's closed.
+// The workers of topic call wait before sending an event to conn.
+func SubscribeAs(
+	topic *Topic,
+	conn *websocket.Conn,
+	identity string,
+	wait func(),
+) error {
+	//nolint:exhaustruct //other fields are optional
+	_, err := topic.subscribe(
+		context.Background(),
+		conn,
+		subscribeRequest{
+			identity: identity,
+			filter: func(_ encodedEvent) bool {
+				wait()
+				return true
+			},
+		},
+	)
+	return err
+}
@@ -0,0 +1,195 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	errortools "github.com/XDoubleU/essentia/pkg/errors"
+)
+
+// FilterDto is used by clients to only receive a subset of the events of a
+// [Topic]. Name refers to a predicate registered using [AddEventFilter],
+// which receives Params. Fields maps (dot separated) JSON fields of an
+// event to the value they should equal. When both are provided,
+// events have to match both.
+type FilterDto struct {
+	Name   string          `json:"name,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Fields map[string]any  `json:"fields,omitempty"`
+} //	@name	FilterDto
+
+// FilteredSubscribeMessageDto can be implemented by a [SubscribeMessageDto]
+// to only receive the events of a [Topic] matching a [FilterDto].
+type FilteredSubscribeMessageDto interface {
+	SubscribeMessageDto
+	Filter() *FilterDto
+}
+
+// EventPredicate decides whether an event of a [Topic] should be sent
+// to a [Subscriber], using the params provided in its [FilterDto].
+type EventPredicate[E any, P any] func(event E, params P) bool
+
+// eventFilter returns true if an event should be sent to a [Subscriber].
+type eventFilter = func(event encodedEvent) bool
+
+type filterFactory = func(params json.RawMessage) (eventFilter, error)
+
+// AddEventFilter registers an [EventPredicate] on a [Topic], which can be
+// used by clients by providing its name in a [FilterDto].
+// Events that aren't of type E are decoded from JSON first.
+func AddEventFilter[E any, P any](
+	topic *Topic,
+	name string,
+	predicate EventPredicate[E, P],
+) error {
+	topic.mu.Lock()
+	defer topic.mu.Unlock()
+
+	_, ok := topic.filters[name]
+	if ok {
+		return fmt.Errorf("filter '%s' has already been added", name)
+	}
+
+	topic.filters[name] = func(data json.RawMessage) (eventFilter, error) {
+		var params P
+		if len(data) > 0 {
+			err := json.Unmarshal(data, &params)
+			if err != nil {
+				return nil, errortools.NewBadRequestError(err)
+			}
+		}
+
+		return func(event encodedEvent) bool {
+			value, ok := event.event.(E)
+			if !ok {
+				err := event.fields.decode(&value)
+				if err != nil {
+					return false
+				}
+			}
+
+			return predicate(value, params)
+		}, nil
+	}
+
+	return nil
+}
+
+// buildFilter creates the [eventFilter] described by a [FilterDto].
+func (t *Topic) buildFilter(filter *FilterDto) (eventFilter, error) {
+	if filter == nil || (filter.Name == "" && len(filter.Fields) == 0) {
+		//nolint:nilnil //without filter all events are sent
+		return nil, nil
+	}
+
+	predicate := func(_ encodedEvent) bool { return true }
+
+	if filter.Name != "" {
+		t.mu.RLock()
+		factory, ok := t.filters[filter.Name]
+		t.mu.RUnlock()
+
+		if !ok {
+			return nil, errortools.NewBadRequestError(
+				fmt.Errorf("filter '%s' doesn't exist", filter.Name),
+			)
+		}
+
+		var err error
+		predicate, err = factory(filter.Params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fields := filter.Fields
+	return func(event encodedEvent) bool {
+		return predicate(event) && event.fields.match(fields)
+	}, nil
+}
+
+// eventFields lazily decodes an event into its JSON fields.
+// This happens at most once per event, as it is shared by all [Subscriber]s.
+type eventFields struct {
+	once   *sync.Once
+	event  any
+	data   []byte
+	values map[string]any
+	err    error
+}
+
+func newEventFields(event any) *eventFields {
+	return &eventFields{
+		once:   &sync.Once{},
+		event:  event,
+		data:   nil,
+		values: nil,
+		err:    nil,
+	}
+}
+
+func (f *eventFields) load() {
+	f.once.Do(func() {
+		raw, ok := f.event.(json.RawMessage)
+		if ok {
+			f.data = raw
+		} else {
+			f.data, f.err = json.Marshal(f.event)
+			if f.err != nil {
+				return
+			}
+		}
+
+		// events which aren't JSON objects don't have fields
+		_ = json.Unmarshal(f.data, &f.values)
+	})
+}
+
+// decode decodes the JSON of an event into value.
+func (f *eventFields) decode(value any) error {
+	f.load()
+	if f.err != nil {
+		return f.err
+	}
+
+	return json.Unmarshal(f.data, value)
+}
+
+// match returns true if all fields equal the provided values.
+func (f *eventFields) match(fields map[string]any) bool {
+	if len(fields) == 0 {
+		return true
+	}
+
+	f.load()
+
+	for path, expected := range fields {
+		actual, ok := lookupField(f.values, path)
+		if !ok || !reflect.DeepEqual(actual, expected) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func lookupField(values map[string]any, path string) (any, bool) {
+	var current any = values
+
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = object[key]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
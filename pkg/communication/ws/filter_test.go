@@ -0,0 +1,206 @@
+package ws_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	wstools "github.com/XDoubleU/essentia/pkg/communication/ws"
+	errortools "github.com/XDoubleU/essentia/pkg/errors"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type TestFilterSubscribeMsg struct {
+	TopicName   string             `json:"topicName"`
+	EventFilter *wstools.FilterDto `json:"filter"`
+}
+
+func (s TestFilterSubscribeMsg) Validate() (bool, map[string]string) {
+	v := validate.New()
+
+	validate.Check(v, "topicName", s.TopicName, validate.IsNotEmpty)
+
+	return v.Valid(), v.Errors()
+}
+
+func (s TestFilterSubscribeMsg) Topic() string {
+	return s.TopicName
+}
+
+func (s TestFilterSubscribeMsg) Filter() *wstools.FilterDto {
+	return s.EventFilter
+}
+
+type TestLocation struct {
+	ID int `json:"id"`
+}
+
+type TestFilterEvent struct {
+	ID       int          `json:"id"`
+	Status   string       `json:"status"`
+	Location TestLocation `json:"location"`
+}
+
+type TestMinIDParams struct {
+	Min int `json:"min"`
+}
+
+func setupFilter(t *testing.T) (*wstools.Topic, *httptest.Server) {
+	t.Helper()
+
+	ws := wstools.CreateWebSocketHandler[TestFilterSubscribeMsg](
+		logging.NewNopLogger(),
+		1,
+		10,
+	)
+
+	topic, err := ws.AddTopic(
+		"filter",
+		[]string{},
+		func(_ context.Context, _ *wstools.Topic) (any, error) {
+			return TestResponse{Ok: true}, nil
+		},
+	)
+	require.Nil(t, err)
+
+	err = wstools.AddEventFilter(
+		topic,
+		"minId",
+		func(event TestFilterEvent, params TestMinIDParams) bool {
+			return event.ID >= params.Min
+		},
+	)
+	require.Nil(t, err)
+
+	ts := httptest.NewServer(ws.Handler())
+	t.Cleanup(ts.Close)
+
+	return topic, ts
+}
+
+func enqueueFilterEvents(topic *wstools.Topic) {
+	topic.EnqueueEvent(TestFilterEvent{
+		ID:       1,
+		Status:   "closed",
+		Location: TestLocation{ID: 1},
+	})
+	topic.EnqueueEvent(TestFilterEvent{
+		ID:       2,
+		Status:   "open",
+		Location: TestLocation{ID: 1},
+	})
+	topic.EnqueueEvent(TestFilterEvent{
+		ID:       3,
+		Status:   "open",
+		Location: TestLocation{ID: 2},
+	})
+}
+
+func TestFilterFields(t *testing.T) {
+	topic, ts := setupFilter(t)
+
+	//nolint:exhaustruct //other fields are optional
+	conn := dialAndSubscribe(t, ts, TestFilterSubscribeMsg{
+		TopicName: "filter",
+		EventFilter: &wstools.FilterDto{
+			Fields: map[string]any{"status": "open", "location.id": 2},
+		},
+	})
+	response := readMessage[TestResponse](t, conn)
+	assert.True(t, response.Ok)
+
+	enqueueFilterEvents(topic)
+
+	event := readMessage[TestFilterEvent](t, conn)
+	assert.Equal(t, 3, event.ID)
+}
+
+func TestFilterPredicate(t *testing.T) {
+	topic, ts := setupFilter(t)
+
+	//nolint:exhaustruct //other fields are optional
+	conn := dialAndSubscribe(t, ts, TestFilterSubscribeMsg{
+		TopicName: "filter",
+		EventFilter: &wstools.FilterDto{
+			Name:   "minId",
+			Params: json.RawMessage(`{"min":2}`),
+		},
+	})
+	_ = readMessage[TestResponse](t, conn)
+
+	enqueueFilterEvents(topic)
+
+	event := readMessage[TestFilterEvent](t, conn)
+	assert.Equal(t, 2, event.ID)
+	event = readMessage[TestFilterEvent](t, conn)
+	assert.Equal(t, 3, event.ID)
+}
+
+func TestFilterPredicateRawEvent(t *testing.T) {
+	topic, ts := setupFilter(t)
+
+	//nolint:exhaustruct //other fields are optional
+	conn := dialAndSubscribe(t, ts, TestFilterSubscribeMsg{
+		TopicName: "filter",
+		EventFilter: &wstools.FilterDto{
+			Name:   "minId",
+			Params: json.RawMessage(`{"min":2}`),
+			Fields: map[string]any{"status": "open"},
+		},
+	})
+	_ = readMessage[TestResponse](t, conn)
+
+	// events received through a broker are raw JSON
+	topic.EnqueueEvent(json.RawMessage(`{"id":1,"status":"open"}`))
+	topic.EnqueueEvent(json.RawMessage(`{"id":2,"status":"closed"}`))
+	topic.EnqueueEvent(json.RawMessage(`{"id":3,"status":"open"}`))
+
+	event := readMessage[TestFilterEvent](t, conn)
+	assert.Equal(t, 3, event.ID)
+}
+
+func TestFilterUnknown(t *testing.T) {
+	_, ts := setupFilter(t)
+
+	//nolint:exhaustruct //other fields are optional
+	conn := dialAndSubscribe(t, ts, TestFilterSubscribeMsg{
+		TopicName:   "filter",
+		EventFilter: &wstools.FilterDto{Name: "unknown"},
+	})
+
+	errorDto := readMessage[errortools.ErrorDto](t, conn)
+	assert.Equal(t, http.StatusBadRequest, errorDto.Status)
+	assert.Equal(t, "filter 'unknown' doesn't exist", errorDto.Message)
+}
+
+func TestFilterInvalidParams(t *testing.T) {
+	_, ts := setupFilter(t)
+
+	//nolint:exhaustruct //other fields are optional
+	conn := dialAndSubscribe(t, ts, TestFilterSubscribeMsg{
+		TopicName: "filter",
+		EventFilter: &wstools.FilterDto{
+			Name:   "minId",
+			Params: json.RawMessage(`{"min":"two"}`),
+		},
+	})
+
+	errorDto := readMessage[errortools.ErrorDto](t, conn)
+	assert.Equal(t, http.StatusBadRequest, errorDto.Status)
+}
+
+func TestAddEventFilterTwice(t *testing.T) {
+	topic, _ := setupFilter(t)
+
+	err := wstools.AddEventFilter(
+		topic,
+		"minId",
+		func(_ TestFilterEvent, _ TestMinIDParams) bool { return true },
+	)
+	assert.EqualError(t, err, "filter 'minId' has already been added")
+}
@@ -0,0 +1,128 @@
+package ws
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+const epochLength = 8
+
+// SequencedEventDto is used to send events of a [Topic] with history enabled.
+// The epoch and sequence can be used by clients to resume from where they
+// left off. Sequences are only known by the instance which sent them,
+// which is identified by the epoch.
+type SequencedEventDto struct {
+	Topic    string `json:"topic"`
+	Epoch    string `json:"epoch"`
+	Sequence uint64 `json:"sequence"`
+	Event    any    `json:"event"`
+} //	@name	SequencedEventDto
+
+// ReplayRequest describes which past events of a [Topic] should be replayed.
+// When SinceSequence is provided all events after that sequence are replayed,
+// which requires Epoch to be the epoch of the history of the [Topic].
+// Otherwise the last LastEvents events are replayed.
+type ReplayRequest struct {
+	SinceSequence *uint64
+	Epoch         string
+	LastEvents    int
+}
+
+// ReplayableSubscribeMessageDto can be implemented by a [SubscribeMessageDto]
+// to request events a client missed from a [Topic] with history enabled.
+type ReplayableSubscribeMessageDto interface {
+	SubscribeMessageDto
+	Replay() *ReplayRequest
+}
+
+// history is a bounded ring buffer of the most recent events of a [Topic].
+// enqueueMu is locked before releasing mu to enqueue an event,
+// which keeps the events on the queue in order of their sequence.
+type history struct {
+	mu           *sync.Mutex
+	enqueueMu    *sync.Mutex
+	epoch        string
+	events       []encodedEvent
+	start        int
+	length       int
+	lastSequence uint64
+}
+
+func newHistory(size int) *history {
+	epoch := make([]byte, epochLength)
+	_, _ = rand.Read(epoch)
+
+	return &history{
+		mu:           &sync.Mutex{},
+		enqueueMu:    &sync.Mutex{},
+		epoch:        hex.EncodeToString(epoch),
+		events:       make([]encodedEvent, size),
+		start:        0,
+		length:       0,
+		lastSequence: 0,
+	}
+}
+
+// add stores an encoded event, its sequence becomes the last sequence.
+// The caller should hold the lock of the history.
+func (h *history) add(event encodedEvent) {
+	h.lastSequence = event.sequence
+
+	end := (h.start + h.length) % len(h.events)
+	h.events[end] = event
+
+	if h.length < len(h.events) {
+		h.length++
+	} else {
+		h.start = (h.start + 1) % len(h.events)
+	}
+}
+
+// replay returns the events matching a [ReplayRequest].
+// An error is returned when the requested events aren't available anymore.
+// The caller should hold the lock of the history.
+func (h *history) replay(request ReplayRequest) ([]encodedEvent, error) {
+	if request.SinceSequence == nil {
+		amount := min(max(request.LastEvents, 0), h.length)
+		return h.slice(h.length-amount, h.length), nil
+	}
+
+	since := *request.SinceSequence
+	if request.Epoch != h.epoch {
+		return nil, fmt.Errorf(
+			"can't replay events since sequence %d of unknown epoch '%s'",
+			since,
+			request.Epoch,
+		)
+	}
+
+	if since > h.lastSequence {
+		return nil, fmt.Errorf(
+			"can't replay events since sequence %d, last sequence is %d",
+			since,
+			h.lastSequence,
+		)
+	}
+
+	oldestSequence := h.lastSequence - uint64(h.length) + 1
+	if since+1 < oldestSequence {
+		return nil, fmt.Errorf(
+			"can't replay events since sequence %d, oldest available sequence is %d",
+			since,
+			oldestSequence,
+		)
+	}
+
+	//nolint:gosec //since is at most h.length behind lastSequence
+	return h.slice(h.length-int(h.lastSequence-since), h.length), nil
+}
+
+func (h *history) slice(from int, to int) []encodedEvent {
+	result := make([]encodedEvent, 0, to-from)
+	for i := from; i < to; i++ {
+		result = append(result, h.events[(h.start+i)%len(h.events)])
+	}
+	return result
+}
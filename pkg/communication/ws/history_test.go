@@ -0,0 +1,344 @@
+package ws_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	wstools "github.com/XDoubleU/essentia/pkg/communication/ws"
+	errortools "github.com/XDoubleU/essentia/pkg/errors"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/validate"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type TestReplaySubscribeMsg struct {
+	TopicName     string  `json:"topicName"`
+	SinceSequence *uint64 `json:"sinceSequence"`
+	Epoch         string  `json:"epoch"`
+	LastEvents    int     `json:"lastEvents"`
+}
+
+func (s TestReplaySubscribeMsg) Validate() (bool, map[string]string) {
+	v := validate.New()
+
+	validate.Check(v, "topicName", s.TopicName, validate.IsNotEmpty)
+
+	return v.Valid(), v.Errors()
+}
+
+func (s TestReplaySubscribeMsg) Topic() string {
+	return s.TopicName
+}
+
+func (s TestReplaySubscribeMsg) Replay() *wstools.ReplayRequest {
+	if s.SinceSequence == nil && s.LastEvents == 0 {
+		return nil
+	}
+
+	return &wstools.ReplayRequest{
+		SinceSequence: s.SinceSequence,
+		Epoch:         s.Epoch,
+		LastEvents:    s.LastEvents,
+	}
+}
+
+type TestSequencedEvent struct {
+	Topic    string `json:"topic"`
+	Epoch    string `json:"epoch"`
+	Sequence uint64 `json:"sequence"`
+	Event    int    `json:"event"`
+}
+
+func setupHistory(
+	t *testing.T,
+	historySize int,
+	amountEvents int,
+) (*wstools.Topic, *httptest.Server) {
+	t.Helper()
+
+	ws := wstools.CreateWebSocketHandler[TestReplaySubscribeMsg](
+		logging.NewNopLogger(),
+		1,
+		10,
+	)
+
+	topic, err := ws.AddTopic(
+		"history",
+		[]string{"http://localhost"},
+		func(_ context.Context, _ *wstools.Topic) (any, error) {
+			return TestResponse{Ok: true}, nil
+		},
+	)
+	require.Nil(t, err)
+
+	topic.EnableHistory(historySize)
+	for i := 1; i <= amountEvents; i++ {
+		topic.EnqueueEvent(i)
+	}
+
+	ts := httptest.NewServer(ws.Handler())
+	t.Cleanup(ts.Close)
+
+	return topic, ts
+}
+
+func dialAndSubscribe(
+	t *testing.T,
+	ts *httptest.Server,
+	msg any,
+) *websocket.Conn {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+	conn, _, err := websocket.Dial(ctx, wsURL, nil)
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = conn.CloseNow() })
+
+	writeMessage(t, conn, msg)
+
+	return conn
+}
+
+// acceptConn returns the server and client side of a connection,
+// of which the server side doesn't read messages.
+func acceptConn(t *testing.T) (*websocket.Conn, *websocket.Conn) {
+	t.Helper()
+
+	serverConns := make(chan *websocket.Conn, 1)
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			conn, err := websocket.Accept(w, r, nil)
+			if err != nil {
+				return
+			}
+
+			ctx := conn.CloseRead(r.Context())
+			serverConns <- conn
+			<-ctx.Done()
+		},
+	))
+	t.Cleanup(ts.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+	clientConn, _, err := websocket.Dial(ctx, wsURL, nil)
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = clientConn.CloseNow() })
+
+	return <-serverConns, clientConn
+}
+
+// blockWorker returns a wait function for [wstools.SubscribeAs], which
+// signals started and blocks the worker calling it until release is closed.
+func blockWorker() (chan struct{}, chan struct{}, func()) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	return started, release, func() {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+	}
+}
+
+func writeMessage(t *testing.T, conn *websocket.Conn, msg any) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := wsjson.Write(ctx, conn, msg)
+	require.Nil(t, err)
+}
+
+func readMessage[T any](t *testing.T, conn *websocket.Conn) T {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var result T
+	err := wsjson.Read(ctx, conn, &result)
+	require.Nil(t, err)
+
+	return result
+}
+
+func TestHistoryReplaySinceSequence(t *testing.T) {
+	topic, ts := setupHistory(t, 3, 5)
+
+	since := uint64(2)
+	conn := dialAndSubscribe(t, ts, TestReplaySubscribeMsg{
+		TopicName:     "history",
+		SinceSequence: &since,
+		Epoch:         topic.HistoryEpoch(),
+		LastEvents:    0,
+	})
+
+	for _, sequence := range []uint64{3, 4, 5} {
+		event := readMessage[TestSequencedEvent](t, conn)
+		assert.Equal(t, "history", event.Topic)
+		assert.Equal(t, sequence, event.Sequence)
+		//nolint:gosec //sequence is small
+		assert.Equal(t, int(sequence), event.Event)
+	}
+}
+
+func TestHistoryReplayLastEvents(t *testing.T) {
+	topic, ts := setupHistory(t, 3, 5)
+
+	conn := dialAndSubscribe(t, ts, TestReplaySubscribeMsg{
+		TopicName:     "history",
+		SinceSequence: nil,
+		Epoch:         "",
+		LastEvents:    2,
+	})
+
+	for _, sequence := range []uint64{4, 5} {
+		event := readMessage[TestSequencedEvent](t, conn)
+		assert.Equal(t, topic.HistoryEpoch(), event.Epoch)
+		assert.Equal(t, sequence, event.Sequence)
+	}
+}
+
+func TestHistoryReplayGap(t *testing.T) {
+	topic, ts := setupHistory(t, 3, 5)
+
+	since := uint64(1)
+	conn := dialAndSubscribe(t, ts, TestReplaySubscribeMsg{
+		TopicName:     "history",
+		SinceSequence: &since,
+		Epoch:         topic.HistoryEpoch(),
+		LastEvents:    0,
+	})
+
+	errorDto := readMessage[errortools.ErrorDto](t, conn)
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, errorDto.Status)
+	assert.Equal(
+		t,
+		"can't replay events since sequence 1, oldest available sequence is 3",
+		errorDto.Message,
+	)
+
+	response := readMessage[TestResponse](t, conn)
+	assert.True(t, response.Ok)
+}
+
+func TestHistoryReplayUnknownSequence(t *testing.T) {
+	topic, ts := setupHistory(t, 3, 5)
+
+	since := uint64(10)
+	conn := dialAndSubscribe(t, ts, TestReplaySubscribeMsg{
+		TopicName:     "history",
+		SinceSequence: &since,
+		Epoch:         topic.HistoryEpoch(),
+		LastEvents:    0,
+	})
+
+	errorDto := readMessage[errortools.ErrorDto](t, conn)
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, errorDto.Status)
+	assert.Equal(
+		t,
+		"can't replay events since sequence 10, last sequence is 5",
+		errorDto.Message,
+	)
+}
+
+func TestHistoryLiveAfterReplay(t *testing.T) {
+	topic, ts := setupHistory(t, 3, 5)
+
+	since := uint64(4)
+	conn := dialAndSubscribe(t, ts, TestReplaySubscribeMsg{
+		TopicName:     "history",
+		SinceSequence: &since,
+		Epoch:         topic.HistoryEpoch(),
+		LastEvents:    0,
+	})
+
+	event := readMessage[TestSequencedEvent](t, conn)
+	assert.Equal(t, uint64(5), event.Sequence)
+
+	topic.EnqueueEvent(6)
+
+	event = readMessage[TestSequencedEvent](t, conn)
+	assert.Equal(t, uint64(6), event.Sequence)
+	assert.Equal(t, 6, event.Event)
+}
+
+func TestHistoryWithoutReplay(t *testing.T) {
+	_, ts := setupHistory(t, 3, 5)
+
+	conn := dialAndSubscribe(t, ts, TestReplaySubscribeMsg{
+		TopicName:     "history",
+		SinceSequence: nil,
+		Epoch:         "",
+		LastEvents:    0,
+	})
+
+	response := readMessage[TestResponse](t, conn)
+	assert.True(t, response.Ok)
+}
+
+func TestHistoryReplayUnknownEpoch(t *testing.T) {
+	_, ts := setupHistory(t, 3, 5)
+
+	since := uint64(4)
+	conn := dialAndSubscribe(t, ts, TestReplaySubscribeMsg{
+		TopicName:     "history",
+		SinceSequence: &since,
+		Epoch:         "other",
+		LastEvents:    0,
+	})
+
+	errorDto := readMessage[errortools.ErrorDto](t, conn)
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, errorDto.Status)
+	assert.Equal(
+		t,
+		"can't replay events since sequence 4 of unknown epoch 'other'",
+		errorDto.Message,
+	)
+
+	response := readMessage[TestResponse](t, conn)
+	assert.True(t, response.Ok)
+}
+
+func TestHistoryReplayWhileQueueIsFull(t *testing.T) {
+	topic, ts := setupHistory(t, 3, 0)
+
+	serverConn, _ := acceptConn(t)
+	started, release, wait := blockWorker()
+	t.Cleanup(func() { close(release) })
+	require.Nil(t, wstools.SubscribeAs(topic, serverConn, "", wait))
+
+	// the only worker waits on the first event while the others fill the queue
+	topic.EnqueueEvent(1)
+	<-started
+	for i := 2; i <= 11; i++ {
+		topic.EnqueueEvent(i)
+	}
+	go topic.EnqueueEvent(12)
+
+	conn := dialAndSubscribe(t, ts, TestReplaySubscribeMsg{
+		TopicName:     "history",
+		SinceSequence: nil,
+		Epoch:         "",
+		LastEvents:    1,
+	})
+
+	event := readMessage[TestSequencedEvent](t, conn)
+	assert.GreaterOrEqual(t, event.Event, 11)
+}
@@ -0,0 +1,144 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+	"github.com/coder/websocket"
+	"golang.org/x/time/rate"
+)
+
+// ConnectionLimits limits the messages clients can send over a connection.
+// MessagesPerSecond and Burst configure a token bucket per connection,
+// zero MessagesPerSecond disables it. ReadLimit is the maximum size
+// of a message in bytes, zero keeps the default of 32768 bytes.
+type ConnectionLimits struct {
+	MessagesPerSecond float64
+	Burst             int
+	ReadLimit         int64
+}
+
+const defaultReadLimit = 32768
+
+// errReadLimit is returned by [connectionLimiter.read]
+// when a message exceeds the read limit.
+var errReadLimit = errors.New("message exceeds read limit")
+
+// connectionLimiter enforces the [ConnectionLimits] of a connection.
+// Its token bucket is created once the connection is rate limited
+// and kept for the lifetime of the connection, after which
+// only its rate and burst change when other limits apply.
+type connectionLimiter struct {
+	conn      *websocket.Conn
+	limiter   *rate.Limiter
+	readLimit int64
+}
+
+func newConnectionLimiter(
+	conn *websocket.Conn,
+	limits ConnectionLimits,
+) *connectionLimiter {
+	// the read limit is enforced by read instead
+	conn.SetReadLimit(-1)
+
+	limiter := &connectionLimiter{
+		conn:      conn,
+		limiter:   nil,
+		readLimit: defaultReadLimit,
+	}
+	limiter.apply(limits)
+
+	return limiter
+}
+
+// strictestLimits combines the limits of multiple topics,
+// using the lowest message rate and read limit of them.
+func strictestLimits(limits []ConnectionLimits) ConnectionLimits {
+	//nolint:exhaustruct //no limits by default
+	result := ConnectionLimits{}
+
+	for _, topicLimits := range limits {
+		if topicLimits.MessagesPerSecond > 0 &&
+			(result.MessagesPerSecond == 0 ||
+				topicLimits.MessagesPerSecond < result.MessagesPerSecond) {
+			result.MessagesPerSecond = topicLimits.MessagesPerSecond
+			result.Burst = topicLimits.Burst
+		}
+
+		if topicLimits.ReadLimit > 0 &&
+			(result.ReadLimit == 0 || topicLimits.ReadLimit < result.ReadLimit) {
+			result.ReadLimit = topicLimits.ReadLimit
+		}
+	}
+
+	return result
+}
+
+// apply changes the limits of a connection. The tokens
+// of the bucket are kept, so this can't be used to refill it.
+func (l *connectionLimiter) apply(limits ConnectionLimits) {
+	l.readLimit = defaultReadLimit
+	if limits.ReadLimit > 0 {
+		l.readLimit = limits.ReadLimit
+	}
+
+	if l.limiter == nil {
+		if limits.MessagesPerSecond > 0 {
+			l.limiter = rate.NewLimiter(
+				rate.Limit(limits.MessagesPerSecond),
+				max(limits.Burst, 1),
+			)
+		}
+		return
+	}
+
+	if limits.MessagesPerSecond <= 0 {
+		l.limiter.SetLimit(rate.Inf)
+		return
+	}
+
+	l.limiter.SetLimit(rate.Limit(limits.MessagesPerSecond))
+	l.limiter.SetBurst(max(limits.Burst, 1))
+}
+
+// allow returns false and closes the connection
+// when a client sends too many messages.
+func (l *connectionLimiter) allow(ctx context.Context) bool {
+	if l.limiter == nil || l.limiter.Allow() {
+		return true
+	}
+
+	contexttools.Logger(ctx).WarnContext(
+		ctx,
+		"websocket message rate limit exceeded",
+		slog.Float64("messages_per_second", float64(l.limiter.Limit())),
+		slog.Int("burst", l.limiter.Burst()),
+	)
+
+	_ = l.conn.Close(websocket.StatusPolicyViolation, "message rate limit exceeded")
+	return false
+}
+
+// read reads a message. When it exceeds the read limit, the connection
+// is closed with [websocket.StatusMessageTooBig] and errReadLimit is returned.
+func (l *connectionLimiter) read(ctx context.Context) ([]byte, error) {
+	_, reader, err := l.conn.Reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, l.readLimit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > l.readLimit {
+		_ = l.conn.Close(websocket.StatusMessageTooBig, errReadLimit.Error())
+		return nil, errReadLimit
+	}
+
+	return data, nil
+}
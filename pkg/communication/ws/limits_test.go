@@ -0,0 +1,152 @@
+package ws_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	wstools "github.com/XDoubleU/essentia/pkg/communication/ws"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupLimits(
+	t *testing.T,
+	limits wstools.ConnectionLimits,
+	topicLimits *wstools.ConnectionLimits,
+) *httptest.Server {
+	t.Helper()
+
+	ws := wstools.CreateWebSocketHandler[TestSubscribeMsg](
+		logging.NewNopLogger(),
+		1,
+		10,
+	)
+	ws.SetConnectionLimits(limits)
+
+	topic, err := ws.AddTopic(
+		"limits",
+		[]string{},
+		func(_ context.Context, _ *wstools.Topic) (any, error) {
+			return TestResponse{Ok: true}, nil
+		},
+	)
+	require.Nil(t, err)
+
+	if topicLimits != nil {
+		topic.SetConnectionLimits(*topicLimits)
+	}
+
+	ts := httptest.NewServer(ws.Handler())
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+// readCloseStatus reads until the connection is closed by the server.
+func readCloseStatus(t *testing.T, conn *websocket.Conn) websocket.StatusCode {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for {
+		_, _, err := conn.Read(ctx)
+		if err != nil {
+			return websocket.CloseStatus(err)
+		}
+	}
+}
+
+func TestConnectionMessageRateLimit(t *testing.T) {
+	//nolint:exhaustruct //other fields are optional
+	ts := setupLimits(t, wstools.ConnectionLimits{
+		MessagesPerSecond: 1,
+		Burst:             2,
+	}, nil)
+
+	conn := dialAndSubscribe(t, ts, TestSubscribeMsg{TopicName: "limits"})
+	_ = readMessage[TestResponse](t, conn)
+
+	writeMessage(t, conn, TestSubscribeMsg{TopicName: "limits"})
+	_ = readMessage[TestResponse](t, conn)
+
+	writeMessage(t, conn, TestSubscribeMsg{TopicName: "limits"})
+	assert.Equal(t, websocket.StatusPolicyViolation, readCloseStatus(t, conn))
+}
+
+func TestConnectionReadLimit(t *testing.T) {
+	//nolint:exhaustruct //other fields are optional
+	ts := setupLimits(t, wstools.ConnectionLimits{
+		ReadLimit: 64,
+	}, nil)
+
+	conn := dialAndSubscribe(t, ts, TestSubscribeMsg{
+		TopicName: strings.Repeat("a", 100),
+	})
+	assert.Equal(t, websocket.StatusMessageTooBig, readCloseStatus(t, conn))
+}
+
+func TestConnectionTopicLimits(t *testing.T) {
+	//nolint:exhaustruct //other fields are optional
+	ts := setupLimits(t, wstools.ConnectionLimits{
+		ReadLimit: 64,
+	}, &wstools.ConnectionLimits{
+		MessagesPerSecond: 0,
+		Burst:             0,
+		ReadLimit:         1024,
+	})
+
+	conn := dialAndSubscribe(t, ts, TestSubscribeMsg{TopicName: "limits"})
+	_ = readMessage[TestResponse](t, conn)
+
+	// allowed by the limits of the topic
+	writeMessage(t, conn, TestSubscribeMsg{TopicName: strings.Repeat("a", 100)})
+	response := readMessage[map[string]any](t, conn)
+	assert.Equal(t, float64(400), response["status"])
+}
+
+func TestConnectionStrictestTopicLimits(t *testing.T) {
+	ws := wstools.CreateWebSocketHandler[TestSubscribeMsg](
+		logging.NewNopLogger(),
+		1,
+		10,
+	)
+
+	limits := map[string]wstools.ConnectionLimits{
+		"strict":  {MessagesPerSecond: 0.1, Burst: 2, ReadLimit: 0},
+		"lenient": {MessagesPerSecond: 100, Burst: 100, ReadLimit: 0},
+	}
+	for name, topicLimits := range limits {
+		topic, err := ws.AddTopic(
+			name,
+			[]string{},
+			func(_ context.Context, _ *wstools.Topic) (any, error) {
+				return TestResponse{Ok: true}, nil
+			},
+		)
+		require.Nil(t, err)
+		topic.SetConnectionLimits(topicLimits)
+	}
+
+	ts := httptest.NewServer(ws.Handler())
+	t.Cleanup(ts.Close)
+
+	conn := dialAndSubscribe(t, ts, TestSubscribeMsg{TopicName: "strict"})
+	_ = readMessage[TestResponse](t, conn)
+
+	// subscribing again doesn't refill the bucket
+	// and the limits of the lenient topic don't apply
+	writeMessage(t, conn, TestSubscribeMsg{TopicName: "strict"})
+	_ = readMessage[TestResponse](t, conn)
+
+	writeMessage(t, conn, TestSubscribeMsg{TopicName: "lenient"})
+	_ = readMessage[TestResponse](t, conn)
+
+	writeMessage(t, conn, TestSubscribeMsg{TopicName: "lenient"})
+	assert.Equal(t, websocket.StatusPolicyViolation, readCloseStatus(t, conn))
+}
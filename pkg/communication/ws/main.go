@@ -1,4 +1,9 @@
 // Package ws contains several tools for dealing with
 // websockets such as easily setting these up
 // error handling.
+//
+// Upgrading: a [WebSocketHandler] only accepts same-origin requests by
+// default, while all origins were accepted before. Cross-origin clients are
+// rejected with 403 Forbidden, unless their hosts are allowed using
+// [WithOriginPatterns].
 package ws
@@ -0,0 +1,130 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	errortools "github.com/XDoubleU/essentia/pkg/errors"
+	"github.com/XDoubleU/essentia/pkg/tracing"
+	"github.com/XDoubleU/essentia/pkg/validate"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+// RequestMessageDto is used by clients to send a message
+// which should be handled by a registered [MessageHandler].
+// Messages without type or request id are handled as [SubscribeMessageDto].
+type RequestMessageDto struct {
+	Type      string          `json:"type"`
+	RequestID string          `json:"requestId"`
+	Data      json.RawMessage `json:"data"`
+} //	@name	RequestMessageDto
+
+// ReplyDto is sent back after handling a [RequestMessageDto].
+// Either Data or Error is set, depending on the outcome of the [MessageHandler].
+type ReplyDto struct {
+	Type      string               `json:"type"`
+	RequestID string               `json:"requestId"`
+	Data      any                  `json:"data,omitempty"`
+	Error     *errortools.ErrorDto `json:"error,omitempty"`
+} //	@name	ReplyDto
+
+// MessageHandler handles a message sent by a client.
+// The returned data is sent back in a [ReplyDto].
+type MessageHandler[M validate.ValidatedType] func(
+	ctx context.Context,
+	msg M,
+) (any, error)
+
+type messageHandler = func(ctx context.Context, data json.RawMessage) (any, error)
+
+// AddMessageHandler registers a [MessageHandler] on a [WebSocketHandler]
+// which handles all [RequestMessageDto]s with the provided type.
+// The data of these messages is decoded into M and validated first.
+func AddMessageHandler[T SubscribeMessageDto, M validate.ValidatedType](
+	h *WebSocketHandler[T],
+	msgType string,
+	handler MessageHandler[M],
+) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, ok := h.messageHandlers[msgType]
+	if ok {
+		return fmt.Errorf("message type '%s' has already been added", msgType)
+	}
+
+	h.messageHandlers[msgType] = func(
+		ctx context.Context,
+		data json.RawMessage,
+	) (any, error) {
+		var msg M
+		err := json.Unmarshal(data, &msg)
+		if err != nil {
+			return nil, errortools.NewBadRequestError(err)
+		}
+
+		if valid, errors := msg.Validate(); !valid {
+			return nil, errortools.NewFailedValidationError(errors)
+		}
+
+		return handler(ctx, msg)
+	}
+
+	return nil
+}
+
+func (h WebSocketHandler[T]) handleRequest(
+	ctx context.Context,
+	conn *websocket.Conn,
+	request RequestMessageDto,
+) {
+	reply := ReplyDto{
+		Type:      request.Type,
+		RequestID: request.RequestID,
+		Data:      nil,
+		Error:     nil,
+	}
+
+	ctx, span := tracing.Start(
+		ctx,
+		"ws.message "+request.Type,
+		tracing.WithKind(tracing.SpanKindServer),
+		tracing.WithOperation("ws.message"),
+		tracing.WithAttribute("ws.message.type", request.Type),
+		tracing.WithAttribute("ws.message.request_id", request.RequestID),
+	)
+	defer span.End()
+
+	h.mu.RLock()
+	handler, ok := h.messageHandlers[request.Type]
+	h.mu.RUnlock()
+
+	if ok {
+		data, err := handler(ctx, request.Data)
+		if err != nil {
+			errorDto := errorToErrorDto(ctx, err)
+			reply.Error = &errorDto
+
+			if errorDto.Status >= http.StatusInternalServerError {
+				span.RecordError(err)
+			}
+		} else {
+			reply.Data = data
+		}
+	} else {
+		errorDto := newErrorDto(
+			ctx,
+			http.StatusBadRequest,
+			fmt.Sprintf("message type '%s' doesn't exist", request.Type),
+		)
+		reply.Error = &errorDto
+	}
+
+	err := wsjson.Write(ctx, conn, reply)
+	if err != nil {
+		ServerErrorResponse(ctx, conn, err)
+	}
+}
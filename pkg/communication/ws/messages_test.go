@@ -0,0 +1,213 @@
+package ws_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	wstools "github.com/XDoubleU/essentia/pkg/communication/ws"
+	errortools "github.com/XDoubleU/essentia/pkg/errors"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/tracing"
+	"github.com/XDoubleU/essentia/pkg/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type TestEditMsg struct {
+	Value string `json:"value"`
+}
+
+func (m TestEditMsg) Validate() (bool, map[string]string) {
+	v := validate.New()
+
+	validate.Check(v, "value", m.Value, validate.IsNotEmpty)
+
+	return v.Valid(), v.Errors()
+}
+
+type TestEditReply struct {
+	Value string `json:"value"`
+}
+
+type TestReply struct {
+	Type      string               `json:"type"`
+	RequestID string               `json:"requestId"`
+	Data      *TestEditReply       `json:"data"`
+	Error     *errortools.ErrorDto `json:"error"`
+}
+
+func setupMessages(t *testing.T) (*wstools.Topic, *httptest.Server) {
+	t.Helper()
+
+	ws := wstools.CreateWebSocketHandler[TestSubscribeMsg](
+		logging.NewNopLogger(),
+		1,
+		10,
+	)
+
+	topic, err := ws.AddTopic("exists", []string{"http://localhost"}, nil)
+	require.Nil(t, err)
+
+	err = wstools.AddMessageHandler(
+		&ws,
+		"edit",
+		func(_ context.Context, msg TestEditMsg) (any, error) {
+			if msg.Value == "fail" {
+				return nil, errortools.NewBadRequestError(errors.New("can't edit"))
+			}
+
+			topic.EnqueueEvent(TestEditReply(msg))
+			return TestEditReply(msg), nil
+		},
+	)
+	require.Nil(t, err)
+
+	ts := httptest.NewServer(ws.Handler())
+	t.Cleanup(ts.Close)
+
+	return topic, ts
+}
+
+func TestMessageHandler(t *testing.T) {
+	_, ts := setupMessages(t)
+
+	conn := dialAndSubscribe(t, ts, wstools.RequestMessageDto{
+		Type:      "edit",
+		RequestID: "1",
+		Data:      []byte(`{"value":"test"}`),
+	})
+
+	reply := readMessage[TestReply](t, conn)
+	assert.Equal(t, "edit", reply.Type)
+	assert.Equal(t, "1", reply.RequestID)
+	assert.Nil(t, reply.Error)
+	require.NotNil(t, reply.Data)
+	assert.Equal(t, "test", reply.Data.Value)
+}
+
+func TestMessageHandlerAndSubscribe(t *testing.T) {
+	_, ts := setupMessages(t)
+
+	conn := dialAndSubscribe(t, ts, TestSubscribeMsg{TopicName: "exists"})
+	writeMessage(t, conn, wstools.RequestMessageDto{
+		Type:      "edit",
+		RequestID: "2",
+		Data:      []byte(`{"value":"test"}`),
+	})
+
+	// the event is enqueued before the reply is sent,
+	// the order in which they arrive is not guaranteed
+	received := map[string]bool{}
+	for range 2 {
+		reply := readMessage[TestReply](t, conn)
+		received[reply.RequestID] = true
+	}
+
+	assert.Equal(t, map[string]bool{"": true, "2": true}, received)
+}
+
+func TestMessageHandlerErrors(t *testing.T) {
+	_, ts := setupMessages(t)
+
+	conn := dialAndSubscribe(t, ts, wstools.RequestMessageDto{
+		Type:      "edit",
+		RequestID: "1",
+		Data:      []byte(`{"value":"fail"}`),
+	})
+
+	reply := readMessage[TestReply](t, conn)
+	require.NotNil(t, reply.Error)
+	assert.Equal(t, http.StatusBadRequest, reply.Error.Status)
+	assert.Equal(t, "can't edit", reply.Error.Message)
+
+	writeMessage(t, conn, wstools.RequestMessageDto{
+		Type:      "edit",
+		RequestID: "2",
+		Data:      []byte(`{"value":""}`),
+	})
+
+	reply = readMessage[TestReply](t, conn)
+	assert.Equal(t, "2", reply.RequestID)
+	require.NotNil(t, reply.Error)
+	assert.Equal(t, http.StatusUnprocessableEntity, reply.Error.Status)
+	assert.Equal(t, map[string]any{
+		"value": "must be provided",
+	}, reply.Error.Message)
+
+	writeMessage(t, conn, wstools.RequestMessageDto{
+		Type:      "unknown",
+		RequestID: "3",
+		Data:      nil,
+	})
+
+	reply = readMessage[TestReply](t, conn)
+	assert.Equal(t, "3", reply.RequestID)
+	require.NotNil(t, reply.Error)
+	assert.Equal(t, http.StatusBadRequest, reply.Error.Status)
+	assert.Equal(t, "message type 'unknown' doesn't exist", reply.Error.Message)
+}
+
+func TestAddExistingMessageHandler(t *testing.T) {
+	ws := wstools.CreateWebSocketHandler[TestSubscribeMsg](
+		logging.NewNopLogger(),
+		1,
+		10,
+	)
+
+	handler := func(_ context.Context, _ TestEditMsg) (any, error) {
+		return nil, nil
+	}
+
+	err := wstools.AddMessageHandler(&ws, "edit", handler)
+	require.Nil(t, err)
+
+	err = wstools.AddMessageHandler(&ws, "edit", handler)
+	assert.EqualError(t, err, "message type 'edit' has already been added")
+}
+
+func TestMessageHandlerTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracing.SetTracer(tracing.NewOTelTracer(provider))
+	defer tracing.SetTracer(tracing.NewSentryTracer())
+
+	_, ts := setupMessages(t)
+
+	conn := dialAndSubscribe(t, ts, wstools.RequestMessageDto{
+		Type:      "edit",
+		RequestID: "1",
+		Data:      []byte(`{"value":"test"}`),
+	})
+	readMessage[TestReply](t, conn)
+
+	var span *tracetest.SpanStub
+	assert.Eventually(t, func() bool {
+		for _, exported := range exporter.GetSpans() {
+			if exported.Name == "ws.message edit" {
+				span = &exported
+			}
+		}
+		return span != nil
+	}, time.Second, 10*time.Millisecond)
+
+	require.NotNil(t, span)
+	assert.Contains(
+		t,
+		span.Attributes,
+		attribute.String("ws.message.type", "edit"),
+	)
+	assert.Contains(
+		t,
+		span.Attributes,
+		attribute.String("ws.message.request_id", "1"),
+	)
+	assert.Equal(t, codes.Unset, span.Status.Code)
+}
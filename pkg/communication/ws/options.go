@@ -0,0 +1,92 @@
+package ws
+
+import (
+	"net/http"
+
+	"github.com/coder/websocket"
+)
+
+// UpgradeCheck is called before a request is upgraded to a WebSocket.
+// Returning an error rejects the upgrade with the matching HTTP error
+// response, before any frames are sent.
+type UpgradeCheck = func(r *http.Request) error
+
+// Option configures how a [WebSocketHandler] upgrades requests.
+type Option func(options *handlerOptions)
+
+type handlerOptions struct {
+	subprotocols         []string
+	originPatterns       []string
+	compressionMode      websocket.CompressionMode
+	compressionThreshold int
+	upgradeCheck         UpgradeCheck
+}
+
+func newHandlerOptions(options []Option) handlerOptions {
+	result := handlerOptions{
+		subprotocols:         []string{},
+		originPatterns:       []string{},
+		compressionMode:      websocket.CompressionDisabled,
+		compressionThreshold: 0,
+		upgradeCheck:         nil,
+	}
+
+	for _, option := range options {
+		option(&result)
+	}
+
+	return result
+}
+
+// WithSubprotocols sets the subprotocols which are negotiated with clients,
+// in order of preference. The negotiated subprotocol is available through
+// [websocket.Conn.Subprotocol].
+func WithSubprotocols(subprotocols ...string) Option {
+	return func(options *handlerOptions) {
+		options.subprotocols = subprotocols
+	}
+}
+
+// WithCompression enables permessage-deflate using the provided
+// [websocket.CompressionMode]. Only messages of at least threshold bytes
+// are compressed, zero uses the default threshold of the mode.
+func WithCompression(mode websocket.CompressionMode, threshold int) Option {
+	return func(options *handlerOptions) {
+		options.compressionMode = mode
+		options.compressionThreshold = threshold
+	}
+}
+
+// WithOriginPatterns sets the host patterns of the cross-origin requests
+// allowed to connect, which are matched using [path/filepath.Match].
+// Requests from other origins are rejected before upgrading, so when no
+// patterns are set only same-origin requests can connect. Topics without
+// allowed origins accept all origins which can connect.
+// Before, any origin was accepted by default. To keep accepting cross-origin
+// clients, provide their hosts, or "*" to accept all origins.
+func WithOriginPatterns(patterns ...string) Option {
+	return func(options *handlerOptions) {
+		options.originPatterns = patterns
+	}
+}
+
+// WithUpgradeCheck sets an [UpgradeCheck], for example to
+// authenticate requests before upgrading them.
+func WithUpgradeCheck(check UpgradeCheck) Option {
+	return func(options *handlerOptions) {
+		options.upgradeCheck = check
+	}
+}
+
+// acceptOptions returns the [websocket.AcceptOptions] used when upgrading.
+// Origins are verified before upgrading to respond with an [errortools.ErrorDto],
+// but Accept verifies them as well.
+func (options handlerOptions) acceptOptions() *websocket.AcceptOptions {
+	//nolint:exhaustruct //origins should always be verified
+	return &websocket.AcceptOptions{
+		Subprotocols:         options.subprotocols,
+		OriginPatterns:       options.originPatterns,
+		CompressionMode:      options.compressionMode,
+		CompressionThreshold: options.compressionThreshold,
+	}
+}
@@ -0,0 +1,179 @@
+package ws_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	wstools "github.com/XDoubleU/essentia/pkg/communication/ws"
+	errortools "github.com/XDoubleU/essentia/pkg/errors"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupOptions(
+	t *testing.T,
+	topicOrigins []string,
+	options ...wstools.Option,
+) string {
+	t.Helper()
+
+	ws := wstools.CreateWebSocketHandler[TestSubscribeMsg](
+		logging.NewNopLogger(),
+		1,
+		10,
+		options...,
+	)
+
+	_, err := ws.AddTopic(
+		"options",
+		topicOrigins,
+		func(_ context.Context, _ *wstools.Topic) (any, error) {
+			return TestResponse{Ok: true}, nil
+		},
+	)
+	require.Nil(t, err)
+
+	ts := httptest.NewServer(ws.Handler())
+	t.Cleanup(ts.Close)
+
+	return "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+func dialWithOptions(
+	t *testing.T,
+	url string,
+	options *websocket.DialOptions,
+) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, res, err := websocket.Dial(ctx, url, options)
+	if conn != nil {
+		t.Cleanup(func() { _ = conn.CloseNow() })
+	}
+
+	return conn, res, err
+}
+
+func originHeader(origin string) *websocket.DialOptions {
+	//nolint:exhaustruct //other fields are optional
+	return &websocket.DialOptions{
+		HTTPHeader: http.Header{"Origin": []string{origin}},
+	}
+}
+
+func readErrorDto(t *testing.T, res *http.Response) errortools.ErrorDto {
+	t.Helper()
+
+	var errorDto errortools.ErrorDto
+	err := json.NewDecoder(res.Body).Decode(&errorDto)
+	require.Nil(t, err)
+
+	return errorDto
+}
+
+func TestOriginPatterns(t *testing.T) {
+	url := setupOptions(
+		t,
+		[]string{},
+		wstools.WithOriginPatterns("*.example.com"),
+	)
+
+	conn, _, err := dialWithOptions(t, url, originHeader("https://app.example.com"))
+	require.Nil(t, err)
+
+	writeMessage(t, conn, TestSubscribeMsg{TopicName: "options"})
+	response := readMessage[TestResponse](t, conn)
+	assert.True(t, response.Ok)
+
+	_, res, err := dialWithOptions(t, url, originHeader("https://evil.com"))
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.Equal(t, errortools.MessageForbidden, readErrorDto(t, res).Message)
+}
+
+func TestSameOriginDefault(t *testing.T) {
+	url := setupOptions(t, []string{})
+
+	_, res, err := dialWithOptions(t, url, originHeader("https://evil.com"))
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.Equal(t, errortools.MessageForbidden, readErrorDto(t, res).Message)
+}
+
+func TestTopicOriginForbidden(t *testing.T) {
+	url := setupOptions(
+		t,
+		[]string{"http://example.com"},
+		wstools.WithOriginPatterns("example.com", "evil.com"),
+	)
+
+	conn, _, err := dialWithOptions(t, url, originHeader("https://evil.com"))
+	require.Nil(t, err)
+
+	writeMessage(t, conn, TestSubscribeMsg{TopicName: "options"})
+	errorDto := readMessage[errortools.ErrorDto](t, conn)
+	assert.Equal(t, http.StatusForbidden, errorDto.Status)
+}
+
+func TestUpgradeCheck(t *testing.T) {
+	url := setupOptions(
+		t,
+		[]string{},
+		wstools.WithUpgradeCheck(func(r *http.Request) error {
+			if r.Header.Get("Authorization") == "" {
+				return errortools.NewUnauthorizedError(errors.New("missing token"))
+			}
+
+			return nil
+		}),
+	)
+
+	_, res, err := dialWithOptions(t, url, nil)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	assert.Equal(t, "missing token", readErrorDto(t, res).Message)
+
+	//nolint:exhaustruct //other fields are optional
+	_, _, err = dialWithOptions(t, url, &websocket.DialOptions{
+		HTTPHeader: http.Header{"Authorization": []string{"Bearer token"}},
+	})
+	require.Nil(t, err)
+}
+
+func TestSubprotocolsAndCompression(t *testing.T) {
+	url := setupOptions(
+		t,
+		[]string{},
+		wstools.WithSubprotocols("v2", "v1"),
+		wstools.WithCompression(websocket.CompressionContextTakeover, 0),
+	)
+
+	//nolint:exhaustruct //other fields are optional
+	conn, res, err := dialWithOptions(t, url, &websocket.DialOptions{
+		Subprotocols:    []string{"v1"},
+		CompressionMode: websocket.CompressionContextTakeover,
+	})
+	require.Nil(t, err)
+
+	assert.Equal(t, "v1", conn.Subprotocol())
+	assert.Contains(
+		t,
+		res.Header.Get("Sec-WebSocket-Extensions"),
+		"permessage-deflate",
+	)
+
+	writeMessage(t, conn, TestSubscribeMsg{TopicName: "options"})
+	response := readMessage[TestResponse](t, conn)
+	assert.True(t, response.Ok)
+}
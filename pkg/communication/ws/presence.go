@@ -0,0 +1,108 @@
+package ws
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// Types of a [PresenceEventDto].
+const (
+	PresenceJoin  = "join"
+	PresenceLeave = "leave"
+)
+
+// PresenceEventDto is sent to all [Subscriber]s of a [Topic] with presence
+// enabled when a member joins or leaves. A member with multiple
+// connections only joins on its first and leaves on its last connection.
+type PresenceEventDto struct {
+	Topic    string `json:"topic"`
+	Type     string `json:"type"`
+	Identity string `json:"identity"`
+} //	@name	PresenceEventDto
+
+// PresenceSnapshotDto is sent to new [Subscriber]s of a [Topic]
+// with presence enabled and contains all current members.
+type PresenceSnapshotDto struct {
+	Topic   string   `json:"topic"`
+	Members []string `json:"members"`
+} //	@name	PresenceSnapshotDto
+
+// IdentifiedSubscribeMessageDto can be implemented by a [SubscribeMessageDto]
+// to provide the identity used by a [Topic] with presence enabled.
+type IdentifiedSubscribeMessageDto interface {
+	SubscribeMessageDto
+	Identity() string
+}
+
+// IdentityFunc resolves the identity of the client of a request,
+// for example using its authentication. Returning an error
+// rejects the subscription of the client.
+type IdentityFunc = func(r *http.Request) (string, error)
+
+// presence keeps track of the members of a [Topic].
+type presence struct {
+	mu          *sync.Mutex
+	subscribers map[string]string
+	connections map[string]int
+}
+
+func newPresence() *presence {
+	return &presence{
+		mu:          &sync.Mutex{},
+		subscribers: make(map[string]string),
+		connections: make(map[string]int),
+	}
+}
+
+// join adds a [Subscriber] with the provided identity.
+// Returns true if this is the first connection of this identity.
+func (p *presence) join(subscriberID string, identity string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.subscribers[subscriberID]; ok {
+		return false
+	}
+
+	p.subscribers[subscriberID] = identity
+	p.connections[identity]++
+
+	return p.connections[identity] == 1
+}
+
+// leave removes a [Subscriber], which is allowed to happen more than once.
+// Returns the identity and true if this was its last connection.
+func (p *presence) leave(subscriberID string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	identity, ok := p.subscribers[subscriberID]
+	if !ok {
+		return "", false
+	}
+
+	delete(p.subscribers, subscriberID)
+	p.connections[identity]--
+
+	if p.connections[identity] > 0 {
+		return identity, false
+	}
+
+	delete(p.connections, identity)
+	return identity, true
+}
+
+// members returns the sorted identities of all members.
+func (p *presence) members() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	members := make([]string, 0, len(p.connections))
+	for identity := range p.connections {
+		members = append(members, identity)
+	}
+	slices.Sort(members)
+
+	return members
+}
@@ -0,0 +1,259 @@
+package ws_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	wstools "github.com/XDoubleU/essentia/pkg/communication/ws"
+	errortools "github.com/XDoubleU/essentia/pkg/errors"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/validate"
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type TestPresenceSubscribeMsg struct {
+	TopicName string `json:"topicName"`
+	User      string `json:"user"`
+}
+
+func (s TestPresenceSubscribeMsg) Validate() (bool, map[string]string) {
+	v := validate.New()
+
+	validate.Check(v, "topicName", s.TopicName, validate.IsNotEmpty)
+
+	return v.Valid(), v.Errors()
+}
+
+func (s TestPresenceSubscribeMsg) Topic() string {
+	return s.TopicName
+}
+
+func (s TestPresenceSubscribeMsg) Identity() string {
+	return s.User
+}
+
+type TestPresenceMsg struct {
+	Topic    string   `json:"topic"`
+	Type     string   `json:"type"`
+	Identity string   `json:"identity"`
+	Members  []string `json:"members"`
+}
+
+func setupPresence(
+	t *testing.T,
+	identityFunc wstools.IdentityFunc,
+) (*wstools.Topic, *httptest.Server) {
+	t.Helper()
+
+	ws := wstools.CreateWebSocketHandler[TestPresenceSubscribeMsg](
+		logging.NewNopLogger(),
+		1,
+		10,
+	)
+
+	if identityFunc != nil {
+		ws.SetIdentityFunc(identityFunc)
+	}
+
+	topic, err := ws.AddTopic("presence", []string{}, nil)
+	require.Nil(t, err)
+
+	topic.EnablePresence()
+
+	ts := httptest.NewServer(ws.Handler())
+	t.Cleanup(ts.Close)
+
+	return topic, ts
+}
+
+// readPresence reads messages until one matches the provided predicate.
+func readPresence(
+	t *testing.T,
+	conn *websocket.Conn,
+	predicate func(msg TestPresenceMsg) bool,
+) TestPresenceMsg {
+	t.Helper()
+
+	for {
+		msg := readMessage[TestPresenceMsg](t, conn)
+		if predicate(msg) {
+			return msg
+		}
+	}
+}
+
+func isSnapshot(msg TestPresenceMsg) bool {
+	return msg.Type == ""
+}
+
+func isEvent(eventType string, identity string) func(msg TestPresenceMsg) bool {
+	return func(msg TestPresenceMsg) bool {
+		return msg.Type == eventType && msg.Identity == identity
+	}
+}
+
+func waitForMembers(t *testing.T, topic *wstools.Topic, members []string) {
+	t.Helper()
+
+	assert.Eventually(t, func() bool {
+		return slices.Equal(topic.Presence(), members)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestPresenceJoinAndSnapshot(t *testing.T) {
+	topic, ts := setupPresence(t, nil)
+
+	alice := dialAndSubscribe(t, ts, TestPresenceSubscribeMsg{
+		TopicName: "presence",
+		User:      "alice",
+	})
+	snapshot := readPresence(t, alice, isSnapshot)
+	assert.Equal(t, []string{"alice"}, snapshot.Members)
+
+	bob := dialAndSubscribe(t, ts, TestPresenceSubscribeMsg{
+		TopicName: "presence",
+		User:      "bob",
+	})
+	snapshot = readPresence(t, bob, isSnapshot)
+	assert.Equal(t, "presence", snapshot.Topic)
+	assert.Equal(t, []string{"alice", "bob"}, snapshot.Members)
+
+	event := readPresence(t, alice, isEvent(wstools.PresenceJoin, "bob"))
+	assert.Equal(t, "presence", event.Topic)
+
+	assert.Equal(t, []string{"alice", "bob"}, topic.Presence())
+}
+
+func TestPresenceLeaveWithoutCloseFrame(t *testing.T) {
+	topic, ts := setupPresence(t, nil)
+
+	alice := dialAndSubscribe(t, ts, TestPresenceSubscribeMsg{
+		TopicName: "presence",
+		User:      "alice",
+	})
+	_ = readPresence(t, alice, isSnapshot)
+
+	bob := dialAndSubscribe(t, ts, TestPresenceSubscribeMsg{
+		TopicName: "presence",
+		User:      "bob",
+	})
+	_ = readPresence(t, bob, isSnapshot)
+
+	// drop the connection without sending a close frame
+	require.Nil(t, bob.CloseNow())
+
+	_ = readPresence(t, alice, isEvent(wstools.PresenceLeave, "bob"))
+	waitForMembers(t, topic, []string{"alice"})
+	assert.Equal(t, 1, topic.SubscriberCount())
+}
+
+func TestPresenceMultipleConnections(t *testing.T) {
+	topic, ts := setupPresence(t, nil)
+
+	alice := dialAndSubscribe(t, ts, TestPresenceSubscribeMsg{
+		TopicName: "presence",
+		User:      "alice",
+	})
+	_ = readPresence(t, alice, isSnapshot)
+
+	bob1 := dialAndSubscribe(t, ts, TestPresenceSubscribeMsg{
+		TopicName: "presence",
+		User:      "bob",
+	})
+	_ = readPresence(t, bob1, isSnapshot)
+
+	bob2 := dialAndSubscribe(t, ts, TestPresenceSubscribeMsg{
+		TopicName: "presence",
+		User:      "bob",
+	})
+	snapshot := readPresence(t, bob2, isSnapshot)
+	assert.Equal(t, []string{"alice", "bob"}, snapshot.Members)
+
+	require.Nil(t, bob1.CloseNow())
+	assert.Eventually(t, func() bool {
+		return topic.SubscriberCount() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"alice", "bob"}, topic.Presence())
+
+	require.Nil(t, bob2.CloseNow())
+	_ = readPresence(t, alice, isEvent(wstools.PresenceLeave, "bob"))
+	waitForMembers(t, topic, []string{"alice"})
+}
+
+func TestPresenceIdentityFunc(t *testing.T) {
+	topic, ts := setupPresence(t, func(r *http.Request) (string, error) {
+		user := r.URL.Query().Get("user")
+		if user == "" {
+			return "", errortools.NewUnauthorizedError(errors.New("no user"))
+		}
+
+		return user, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+	conn, _, err := websocket.Dial(ctx, wsURL+"?user=carol", nil)
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = conn.CloseNow() })
+
+	writeMessage(t, conn, TestPresenceSubscribeMsg{
+		TopicName: "presence",
+		User:      "mallory",
+	})
+	snapshot := readPresence(t, conn, isSnapshot)
+	assert.Equal(t, []string{"carol"}, snapshot.Members)
+	assert.Equal(t, []string{"carol"}, topic.Presence())
+
+	unauthorized := dialAndSubscribe(t, ts, TestPresenceSubscribeMsg{
+		TopicName: "presence",
+		User:      "mallory",
+	})
+	errorDto := readMessage[errortools.ErrorDto](t, unauthorized)
+	assert.Equal(t, http.StatusUnauthorized, errorDto.Status)
+}
+
+func TestPresenceLeaveOnFullQueue(t *testing.T) {
+	topic := wstools.NewTopic(logging.NewNopLogger(), "presence", nil, 1, 1, nil)
+	topic.EnablePresence()
+
+	serverConn, clientConn := acceptConn(t)
+
+	started, release, wait := blockWorker()
+
+	require.Nil(t, wstools.SubscribeAs(topic, serverConn, "bob", wait))
+	_ = readPresence(t, clientConn, isEvent(wstools.PresenceJoin, "bob"))
+	require.Nil(t, serverConn.CloseNow())
+
+	// the only worker waits on the first event while the second fills the queue
+	topic.EnqueueEvent("first")
+	<-started
+	topic.EnqueueEvent("second")
+
+	enqueued := make(chan struct{})
+	go func() {
+		topic.EnqueueEvent("third")
+		close(enqueued)
+	}()
+
+	// the worker unsubscribes bob as its connection is closed
+	close(release)
+
+	select {
+	case <-enqueued:
+	case <-time.After(5 * time.Second):
+		t.Fatal("enqueueing events is blocked")
+	}
+
+	waitForMembers(t, topic, []string{})
+	assert.Equal(t, 0, topic.SubscriberCount())
+}
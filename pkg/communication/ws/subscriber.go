@@ -2,28 +2,47 @@ package ws
 
 import (
 	"context"
+	"errors"
+	"net"
 
 	"github.com/coder/websocket"
-	"github.com/coder/websocket/wsjson"
 	"github.com/google/uuid"
 )
 
+// encodedEvent is an event of a [Topic] which has already been
+// encoded, so it can be written to all [Subscriber]s as is.
+// Unfiltered events are sent to all [Subscriber]s regardless of their filter.
+type encodedEvent struct {
+	sequence    uint64
+	event       any
+	fields      *eventFields
+	unfiltered  bool
+	messageType websocket.MessageType
+	data        []byte
+}
+
 // Subscriber is used to receive messages
 // from a [Topic] and managed the [websocket.Conn].
 type Subscriber struct {
-	id    string
-	ctx   context.Context
-	topic *Topic
-	conn  *websocket.Conn
+	id          string
+	ctx         context.Context
+	topic       *Topic
+	conn        *websocket.Conn
+	minSequence uint64
+	identity    string
+	filter      eventFilter
 }
 
 // NewSubscriber returns a new [Subscriber].
 func NewSubscriber(topic *Topic, conn *websocket.Conn) Subscriber {
 	return Subscriber{
-		id:    uuid.NewString(),
-		ctx:   context.Background(),
-		topic: topic,
-		conn:  conn,
+		id:          uuid.NewString(),
+		ctx:         context.Background(),
+		topic:       topic,
+		conn:        conn,
+		minSequence: 0,
+		identity:    "",
+		filter:      nil,
 	}
 }
 
@@ -34,15 +53,43 @@ func (sub Subscriber) ID() string {
 
 // OnEventCallback is called when a
 // new event is pushed to [Subscriber].
-// If the connection would be closed,
+// Events not matching its filter are skipped.
+// If the connection has been closed,
 // [UnSubscribe] will be called.
 func (sub Subscriber) OnEventCallback(event any) {
-	err := wsjson.Write(sub.ctx, sub.conn, event)
+	encoded, ok := event.(encodedEvent)
+	if !ok {
+		var err error
+		encoded, err = sub.topic.encode(0, event)
+		if err != nil {
+			ServerErrorResponse(sub.ctx, sub.conn, err)
+			return
+		}
+	}
+
+	if encoded.sequence != 0 && encoded.sequence <= sub.minSequence {
+		return
+	}
+
+	if !sub.accepts(encoded) {
+		return
+	}
+
+	sub.send(encoded)
+}
+
+// accepts returns true if an event matches the filter of a [Subscriber].
+func (sub Subscriber) accepts(event encodedEvent) bool {
+	return sub.filter == nil || event.unfiltered || sub.filter(event)
+}
+
+func (sub Subscriber) send(event encodedEvent) {
+	err := sub.conn.Write(sub.ctx, event.messageType, event.data)
 	if err == nil {
 		return
 	}
 
-	if websocket.CloseStatus(err) != -1 {
+	if websocket.CloseStatus(err) != -1 || errors.Is(err, net.ErrClosed) {
 		sub.topic.UnSubscribe(sub)
 		return
 	}
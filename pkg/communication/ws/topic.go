@@ -2,9 +2,16 @@ package ws
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
 	"strings"
+	"sync"
 
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+	"github.com/XDoubleU/essentia/pkg/logging"
 	"github.com/XDoubleU/essentia/pkg/threading"
 	"github.com/coder/websocket"
 )
@@ -13,13 +20,25 @@ import (
 // should be returned when a new subscriber is added to a topic.
 type OnSubscribeCallback = func(ctx context.Context, topic *Topic) (any, error)
 
+var errTopicRemoved = errors.New("topic has been removed")
+
 // Topic is used to efficiently send messages
 // to [Subscriber]s in a WebSocket.
+// Name should only be changed using [WebSocketHandler.UpdateTopicName].
 type Topic struct {
 	Name                string
+	mu                  *sync.RWMutex
+	closed              bool
+	logger              *slog.Logger
 	allowedOrigins      []string
 	eventQueue          *threading.EventQueue
 	onSubscribeCallback OnSubscribeCallback
+	history             *history
+	presence            *presence
+	broker              Broker
+	encoder             Encoder
+	filters             map[string]filterFactory
+	connectionLimits    *ConnectionLimits
 }
 
 // NewTopic creates a new [Topic].
@@ -39,6 +58,9 @@ func NewTopic(
 
 	return &Topic{
 		Name:           name,
+		mu:             &sync.RWMutex{},
+		closed:         false,
+		logger:         logger,
 		allowedOrigins: allowedOrigins,
 		eventQueue: threading.NewEventQueue(
 			logger,
@@ -46,7 +68,199 @@ func NewTopic(
 			channelBufferSize,
 		),
 		onSubscribeCallback: onSubscribeCallback,
+		history:             nil,
+		presence:            nil,
+		broker:              nil,
+		encoder:             JSONEncoder{},
+		filters:             make(map[string]filterFactory),
+		connectionLimits:    nil,
+	}
+}
+
+// EnableHistory makes a [Topic] keep the last historySize events.
+// Events are then sent as [SequencedEventDto]s so clients can request
+// the events they missed using a [ReplayableSubscribeMessageDto].
+// Every instance numbers its events itself, also when they're distributed
+// by a [Broker], so resuming requires clients to reconnect to the same
+// instance, for example using sticky sessions. Replays of sequences of
+// another epoch, such as another instance or a restarted one, are rejected.
+// This should be called before any events are enqueued.
+func (t *Topic) EnableHistory(historySize int) {
+	if historySize <= 0 {
+		t.history = nil
+		return
+	}
+
+	t.history = newHistory(historySize)
+}
+
+// HistoryEpoch returns the epoch of the sequences of a [Topic],
+// which is empty when history isn't enabled.
+func (t *Topic) HistoryEpoch() string {
+	if t.history == nil {
+		return ""
+	}
+
+	return t.history.epoch
+}
+
+// EnablePresence makes a [Topic] keep track of its members,
+// identified by an [IdentityFunc] or an [IdentifiedSubscribeMessageDto].
+// [PresenceEventDto]s are sent when members join or leave and new
+// [Subscriber]s receive a [PresenceSnapshotDto] of the current members.
+// Presence only covers the [Subscriber]s of this instance.
+// This should be called before any [Subscriber]s are added.
+func (t *Topic) EnablePresence() {
+	t.presence = newPresence()
+}
+
+// Presence returns the sorted identities of the current members of a
+// [Topic]. This is empty when presence isn't enabled.
+func (t *Topic) Presence() []string {
+	if t.presence == nil {
+		return []string{}
+	}
+
+	return t.presence.members()
+}
+
+// SetConnectionLimits overrides the [ConnectionLimits] of the
+// [WebSocketHandler] for connections subscribing to this [Topic].
+// When a connection subscribes to multiple topics with limits,
+// the strictest of their limits apply.
+func (t *Topic) SetConnectionLimits(limits ConnectionLimits) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.connectionLimits = &limits
+}
+
+func (t *Topic) limits() *ConnectionLimits {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.connectionLimits
+}
+
+// SetEncoder sets the [Encoder] used by a [Topic].
+// Each event is encoded once and the result is sent to all [Subscriber]s.
+// By default events are encoded as JSON using [JSONEncoder].
+func (t *Topic) SetEncoder(encoder Encoder) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.encoder = encoder
+}
+
+func (t *Topic) getEncoder() Encoder {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.encoder
+}
+
+// encode encodes an event using the [Encoder] of a [Topic].
+// Events with a sequence are wrapped in a [SequencedEventDto].
+func (t *Topic) encode(sequence uint64, event any) (encodedEvent, error) {
+	value := event
+	if sequence != 0 {
+		value = SequencedEventDto{
+			Topic:    t.topicName(),
+			Epoch:    t.history.epoch,
+			Sequence: sequence,
+			Event:    event,
+		}
 	}
+
+	encoder := t.getEncoder()
+
+	data, err := encoder.Encode(value)
+	if err != nil {
+		return encodedEvent{}, err
+	}
+
+	return encodedEvent{
+		sequence:    sequence,
+		event:       event,
+		fields:      newEventFields(event),
+		unfiltered:  false,
+		messageType: encoder.MessageType(),
+		data:        data,
+	}, nil
+}
+
+// SubscriberCount returns the amount of [Subscriber]s
+// connected to this [Topic] on this instance.
+func (t *Topic) SubscriberCount() int {
+	return len(t.eventQueue.Subscribers())
+}
+
+func (t *Topic) topicName() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.Name
+}
+
+func (t *Topic) setName(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.Name = name
+}
+
+func (t *Topic) getBroker() Broker {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.broker
+}
+
+func (t *Topic) setBroker(broker Broker) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.broker = broker
+}
+
+// addSubscriber adds a [Subscriber] unless the [Topic] has been removed.
+// Subscribers added before removal are notified by close.
+func (t *Topic) addSubscriber(sub Subscriber) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.closed {
+		return errTopicRemoved
+	}
+
+	t.eventQueue.AddSubscriber(sub)
+	return nil
+}
+
+// close notifies all [Subscriber]s that this [Topic] has been
+// removed and stops the workers handling its events.
+func (t *Topic) close() {
+	t.mu.Lock()
+	t.closed = true
+	name := t.Name
+	t.mu.Unlock()
+
+	ctx := context.Background()
+	for _, sub := range t.eventQueue.Subscribers() {
+		subscriber, ok := sub.(Subscriber)
+		if !ok {
+			continue
+		}
+
+		ErrorResponse(
+			ctx,
+			subscriber.conn,
+			http.StatusGone,
+			fmt.Sprintf("topic '%s' has been removed", name),
+		)
+	}
+
+	t.eventQueue.Stop()
 }
 
 // Subscribe subscribes a [Subscriber] to this [Topic].
@@ -54,27 +268,273 @@ func NewTopic(
 // If no message handling go routine was
 // running this will be started now.
 func (t *Topic) Subscribe(conn *websocket.Conn) error {
+	//nolint:exhaustruct //other fields are optional
+	_, err := t.subscribe(context.Background(), conn, subscribeRequest{})
+	return err
+}
+
+// subscribeRequest contains the options of a client subscribing to a [Topic].
+type subscribeRequest struct {
+	replay   *ReplayRequest
+	identity string
+	filter   eventFilter
+}
+
+func (t *Topic) subscribe(
+	ctx context.Context,
+	conn *websocket.Conn,
+	request subscribeRequest,
+) (Subscriber, error) {
+	replay := request.replay
+	if t.history == nil {
+		replay = nil
+	}
+
 	sub := NewSubscriber(t, conn)
-	t.eventQueue.AddSubscriber(sub)
+	sub.identity = request.identity
+	sub.filter = request.filter
+
+	var replayErr error
+	if replay == nil {
+		err := t.addSubscriber(sub)
+		if err != nil {
+			return sub, err
+		}
+	} else {
+		sub, replayErr = t.replayAndSubscribe(ctx, sub, *replay)
+		if errors.Is(replayErr, errTopicRemoved) {
+			return sub, replayErr
+		}
+	}
+
+	t.join(sub)
 
-	if t.onSubscribeCallback != nil {
-		event, err := t.onSubscribeCallback(context.Background(), t)
+	if replay == nil || replayErr != nil {
+		if replayErr != nil {
+			// the gap can't be replayed, so the client has to start over
+			contexttools.Logger(ctx).DebugContext(
+				ctx,
+				"can't replay events",
+				slog.String("topic", t.topicName()),
+				logging.ErrAttr(replayErr),
+			)
+		}
+
+		err := t.sendOnSubscribeEvent(sub)
 		if err != nil {
-			return err
+			return sub, err
+		}
+	}
+
+	return sub, t.sendPresenceSnapshot(sub)
+}
+
+// replayAndSubscribe adds a [Subscriber] and sends the requested events.
+// The events are taken and the [Subscriber] is added while the history is
+// locked, so no events are missed, but they're sent after releasing it,
+// so a slow client doesn't block publishing events. Events that were
+// stored before but not yet sent are skipped. Clients can use
+// the sequences to order replayed events and new events.
+func (t *Topic) replayAndSubscribe(
+	ctx context.Context,
+	sub Subscriber,
+	replay ReplayRequest,
+) (Subscriber, error) {
+	t.history.mu.Lock()
+
+	sub.minSequence = t.history.lastSequence
+	events, replayErr := t.history.replay(replay)
+
+	err := t.addSubscriber(sub)
+	t.history.mu.Unlock()
+
+	if err != nil {
+		return sub, err
+	}
+
+	if replayErr != nil {
+		ErrorResponse(
+			ctx,
+			sub.conn,
+			http.StatusRequestedRangeNotSatisfiable,
+			replayErr.Error(),
+		)
+	}
+
+	for _, event := range events {
+		if sub.accepts(event) {
+			sub.send(event)
 		}
+	}
+
+	return sub, replayErr
+}
+
+func (t *Topic) sendOnSubscribeEvent(sub Subscriber) error {
+	if t.onSubscribeCallback == nil {
+		return nil
+	}
+
+	event, err := t.onSubscribeCallback(context.Background(), t)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := t.encode(0, event)
+	if err != nil {
+		return err
+	}
 
-		sub.OnEventCallback(event)
+	sub.send(encoded)
+	return nil
+}
+
+func (t *Topic) sendPresenceSnapshot(sub Subscriber) error {
+	if t.presence == nil {
+		return nil
 	}
 
+	encoded, err := t.encode(0, PresenceSnapshotDto{
+		Topic:   t.topicName(),
+		Members: t.presence.members(),
+	})
+	if err != nil {
+		return err
+	}
+
+	sub.send(encoded)
 	return nil
 }
 
+// join adds a [Subscriber] to the members of a [Topic] with presence enabled.
+func (t *Topic) join(sub Subscriber) {
+	if t.presence == nil || sub.identity == "" {
+		return
+	}
+
+	if t.presence.join(sub.id, sub.identity) {
+		t.broadcastPresence(PresenceJoin, sub.identity)
+	}
+}
+
+// leave removes a [Subscriber] from the members of a [Topic]
+// with presence enabled. This is allowed to happen more than once.
+func (t *Topic) leave(sub Subscriber) {
+	if t.presence == nil {
+		return
+	}
+
+	identity, left := t.presence.leave(sub.id)
+	if left {
+		// the workers of the queue unsubscribe closed connections as well,
+		// which would wait on themselves when enqueueing on a full queue
+		go t.broadcastPresence(PresenceLeave, identity)
+	}
+}
+
+// broadcastPresence sends a [PresenceEventDto] to the [Subscriber]s of this
+// instance. These events aren't stored in the history of a [Topic]
+// and are sent regardless of the filters of [Subscriber]s.
+func (t *Topic) broadcastPresence(eventType string, identity string) {
+	encoded, err := t.encode(0, PresenceEventDto{
+		Topic:    t.topicName(),
+		Type:     eventType,
+		Identity: identity,
+	})
+	if err != nil {
+		t.logEncodeError(err)
+		return
+	}
+
+	encoded.unfiltered = true
+	t.eventQueue.EnqueueEvent(encoded)
+}
+
 // UnSubscribe unsubscribes a [Subscriber] from this [Topic].
+// When presence is enabled, its member leaves if this was its last connection.
 func (t *Topic) UnSubscribe(sub Subscriber) {
 	t.eventQueue.RemoveSubscriber(sub)
+	t.leave(sub)
 }
 
 // EnqueueEvent enqueues an event if there are subscribers on this [Topic].
+// When a [Broker] is used the event is published to all instances instead.
 func (t *Topic) EnqueueEvent(event any) {
-	t.eventQueue.EnqueueEvent(event)
+	broker := t.getBroker()
+	if broker == nil {
+		t.enqueueLocalEvent(event)
+		return
+	}
+
+	payload, err := t.brokerPayload(event)
+	if err != nil {
+		t.logger.Error("failed to marshal event", logging.ErrAttr(err))
+		return
+	}
+
+	name := t.topicName()
+
+	err = broker.Publish(context.Background(), name, payload)
+	if err != nil {
+		t.logger.Error(
+			"failed to publish event",
+			slog.String("topic", name),
+			logging.ErrAttr(err),
+		)
+	}
+}
+
+// brokerPayload returns the payload used to publish an event to a [Broker].
+// Events are published as JSON, unless they are sent as raw frames.
+func (t *Topic) brokerPayload(event any) ([]byte, error) {
+	if _, ok := t.getEncoder().(RawEncoder); ok {
+		return RawEncoder{}.Encode(event)
+	}
+
+	return json.Marshal(event)
+}
+
+// enqueueLocalEvent enqueues an event for the [Subscriber]s of this instance.
+// The event is encoded once, after which it is sent to all [Subscriber]s.
+// When history is enabled the event is stored and gets a sequence.
+// Events stored but not yet sent when a [Subscriber] is added are
+// replayed to it, so these are skipped by [Subscriber.OnEventCallback].
+func (t *Topic) enqueueLocalEvent(event any) {
+	if t.history == nil {
+		encoded, err := t.encode(0, event)
+		if err != nil {
+			t.logEncodeError(err)
+			return
+		}
+
+		t.eventQueue.EnqueueEvent(encoded)
+		return
+	}
+
+	t.history.mu.Lock()
+
+	encoded, err := t.encode(t.history.lastSequence+1, event)
+	if err != nil {
+		t.history.mu.Unlock()
+		t.logEncodeError(err)
+		return
+	}
+
+	t.history.add(encoded)
+
+	// events are enqueued in order of their sequence, but without holding
+	// the history, so a full queue doesn't block new subscribers
+	t.history.enqueueMu.Lock()
+	t.history.mu.Unlock()
+
+	t.eventQueue.EnqueueEvent(encoded)
+	t.history.enqueueMu.Unlock()
+}
+
+func (t *Topic) logEncodeError(err error) {
+	t.logger.Error(
+		"failed to encode event",
+		slog.String("topic", t.topicName()),
+		logging.ErrAttr(err),
+	)
 }
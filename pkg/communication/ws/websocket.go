@@ -1,16 +1,25 @@
 package ws
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/metrics"
 	"github.com/XDoubleU/essentia/pkg/validate"
 	"github.com/coder/websocket"
-	"github.com/coder/websocket/wsjson"
 )
 
 // SubscribeMessageDto is implemented by all messages
@@ -20,37 +29,172 @@ type SubscribeMessageDto interface {
 	Topic() string
 }
 
+// TopicInfo describes a [Topic] of a [WebSocketHandler].
+type TopicInfo struct {
+	Name        string `json:"name"`
+	Subscribers int    `json:"subscribers"`
+} //	@name	TopicInfo
+
 // A WebSocketHandler handles incoming requests to a
 // websocket and makes sure subscriptions are made to the right topics.
+// Topics and message handlers can safely be managed while handling requests.
+// Only same-origin requests are accepted, unless [WithOriginPatterns] is
+// used. Before, any origin was accepted, so cross-origin clients have to be
+// allowed explicitly when upgrading.
 type WebSocketHandler[T SubscribeMessageDto] struct {
 	logger                 *slog.Logger
 	maxTopicWorkers        int
 	topicChannelBufferSize int
+	mu                     *sync.RWMutex
 	topicMap               map[string]*Topic
+	messageHandlers        map[string]messageHandler
+	broker                 Broker
+	identityFunc           IdentityFunc
+	pingInterval           time.Duration
+	connectionLimits       ConnectionLimits
+	options                handlerOptions
 }
 
+const defaultPingInterval = 30 * time.Second
+
 // CreateWebSocketHandler creates a new [WebSocketHandler].
+// The provided [Option]s configure how requests are upgraded.
 func CreateWebSocketHandler[T SubscribeMessageDto](
 	logger *slog.Logger,
 	maxTopicWorkers int,
 	topicChannelBufferSize int,
+	options ...Option,
 ) WebSocketHandler[T] {
 	return WebSocketHandler[T]{
 		logger:                 logger,
 		maxTopicWorkers:        maxTopicWorkers,
 		topicChannelBufferSize: topicChannelBufferSize,
+		mu:                     &sync.RWMutex{},
 		topicMap:               make(map[string]*Topic),
+		messageHandlers:        make(map[string]messageHandler),
+		broker:                 nil,
+		identityFunc:           nil,
+		pingInterval:           defaultPingInterval,
+		//nolint:exhaustruct //no limits by default
+		connectionLimits: ConnectionLimits{},
+		options:          newHandlerOptions(options),
+	}
+}
+
+// SetIdentityFunc sets the [IdentityFunc] used to identify the members
+// of topics with presence enabled. The identity it returns takes precedence
+// over the one provided by an [IdentifiedSubscribeMessageDto].
+// This should be called before [WebSocketHandler.Handler].
+func (h *WebSocketHandler[T]) SetIdentityFunc(identityFunc IdentityFunc) {
+	h.identityFunc = identityFunc
+}
+
+// SetConnectionLimits sets the [ConnectionLimits] of all connections.
+// These can be overridden per topic using [Topic.SetConnectionLimits].
+// Connections exceeding these are closed and the violation is logged.
+// This should be called before [WebSocketHandler.Handler].
+func (h *WebSocketHandler[T]) SetConnectionLimits(limits ConnectionLimits) {
+	h.connectionLimits = limits
+}
+
+// SetPingInterval sets how often connections are pinged,
+// connections that don't respond in time are closed.
+// This way subscriptions of dropped connections are cleaned up,
+// even when no close frame was received. Zero disables pinging.
+// This should be called before [WebSocketHandler.Handler].
+func (h *WebSocketHandler[T]) SetPingInterval(interval time.Duration) {
+	h.pingInterval = interval
+}
+
+// SetBroker makes all topics of a [WebSocketHandler] distribute
+// their events through a [Broker]. This allows subscribers connected
+// to other instances of an application to receive these events too.
+func (h *WebSocketHandler[T]) SetBroker(broker Broker) {
+	h.mu.Lock()
+	h.broker = broker
+
+	for _, topic := range h.topicMap {
+		topic.setBroker(broker)
+	}
+	h.mu.Unlock()
+
+	broker.Listen(h.onBrokerPayload)
+}
+
+func (h *WebSocketHandler[T]) onBrokerPayload(topicName string, payload []byte) {
+	topic, ok := h.getTopic(topicName)
+	if !ok {
+		return
+	}
+
+	topic.enqueueLocalEvent(json.RawMessage(payload))
+}
+
+func (h WebSocketHandler[T]) getTopic(topicName string) (*Topic, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	topic, ok := h.topicMap[topicName]
+	return topic, ok
+}
+
+// Topics returns information about all topics of a [WebSocketHandler],
+// such as the amount of subscribers connected to this instance.
+func (h WebSocketHandler[T]) Topics() []TopicInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	result := make([]TopicInfo, 0, len(h.topicMap))
+	for name, topic := range h.topicMap {
+		result = append(result, TopicInfo{
+			Name:        name,
+			Subscribers: topic.SubscriberCount(),
+		})
 	}
+
+	slices.SortFunc(result, func(a TopicInfo, b TopicInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return result
+}
+
+// RegisterMetrics exposes the amount of subscribers of each [Topic]
+// connected to this instance of a [WebSocketHandler] in registry.
+func (h WebSocketHandler[T]) RegisterMetrics(registry *metrics.Registry) {
+	registry.GaugeFunc(
+		"ws_topic_subscribers",
+		"Amount of subscribers of a topic connected to this instance.",
+		[]string{"topic"},
+		func() []metrics.Sample {
+			topics := h.Topics()
+
+			samples := make([]metrics.Sample, 0, len(topics))
+			for _, topic := range topics {
+				samples = append(samples, metrics.Sample{
+					LabelValues: []string{topic.Name},
+					Value:       float64(topic.Subscribers),
+				})
+			}
+
+			return samples
+		},
+	)
 }
 
 // AddTopic adds a topic to which can be subscribed using a [SubscribeMessageDto].
 // The onSubscribeCallback is called for each
 // new subscriber to fetch data to send them back.
+// Only origins which can connect, see [WithOriginPatterns], can subscribe,
+// allowedOrigins further restricts which of them can subscribe.
 func (h *WebSocketHandler[T]) AddTopic(
 	topicName string,
 	allowedOrigins []string,
 	onSubscribeCallback OnSubscribeCallback,
 ) (*Topic, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	_, ok := h.topicMap[topicName]
 	if ok {
 		return nil, fmt.Errorf("topic '%s' has already been added", topicName)
@@ -64,6 +208,7 @@ func (h *WebSocketHandler[T]) AddTopic(
 		h.topicChannelBufferSize,
 		onSubscribeCallback,
 	)
+	topic.setBroker(h.broker)
 	h.topicMap[topicName] = topic
 
 	return topic, nil
@@ -74,6 +219,9 @@ func (h *WebSocketHandler[T]) UpdateTopicName(
 	topic *Topic,
 	newName string,
 ) (*Topic, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	newTopic, ok := h.topicMap[topic.Name]
 	if !ok {
 		return nil, fmt.Errorf("topic '%s' doesn't exist", topic.Name)
@@ -84,75 +232,300 @@ func (h *WebSocketHandler[T]) UpdateTopicName(
 		return nil, fmt.Errorf("topic '%s' already exists", newName)
 	}
 
-	newTopic.Name = newName
 	delete(h.topicMap, topic.Name)
-	h.topicMap[newTopic.Name] = newTopic
+	newTopic.setName(newName)
+	h.topicMap[newName] = newTopic
 
 	return newTopic, nil
 }
 
 // RemoveTopic removes a topic to which can be subscribed using a [SubscribeMessageDto].
+// Its subscribers are notified and the workers handling its events are stopped.
 func (h *WebSocketHandler[T]) RemoveTopic(topic *Topic) error {
-	_, ok := h.topicMap[topic.Name]
+	h.mu.Lock()
+	removedTopic, ok := h.topicMap[topic.Name]
 	if !ok {
+		h.mu.Unlock()
 		return fmt.Errorf("topic '%s' doesn't exist", topic.Name)
 	}
 
 	delete(h.topicMap, topic.Name)
+	h.mu.Unlock()
+
+	removedTopic.close()
 	return nil
 }
 
 // Handler returns the [http.HandlerFunc] of a [WebSocketHandler].
 func (h WebSocketHandler[T]) Handler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		//nolint:exhaustruct //other fields are optional
-		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
-			InsecureSkipVerify: true,
-		})
+		if !h.checkUpgrade(w, r) {
+			return
+		}
+
+		conn, err := websocket.Accept(w, r, h.options.acceptOptions())
 		if err != nil {
 			UpgradeErrorResponse(w, r, err)
 			return
 		}
 
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		r = r.WithContext(ctx)
+		go h.ping(ctx, conn)
+
+		limiter := newConnectionLimiter(conn, h.connectionLimits)
+
+		// subscriptions are cleaned up once the connection is gone
+		subscriptions := []Subscriber{}
+		defer func() {
+			for _, sub := range subscriptions {
+				sub.topic.UnSubscribe(sub)
+			}
+		}()
+
 		// in case you want to subscribe on multiple topics
+		// or send requests to registered message handlers
 		for {
-			var msg T
-			err = wsjson.Read(r.Context(), conn, &msg)
+			var data []byte
+			data, err = limiter.read(ctx)
+			if errors.Is(err, errReadLimit) {
+				contexttools.Logger(ctx).WarnContext(
+					ctx,
+					"websocket read limit exceeded",
+					logging.ErrAttr(err),
+				)
+				return
+			}
+
 			if err != nil {
-				ServerErrorResponse(r.Context(), conn, err)
+				ServerErrorResponse(ctx, conn, err)
 				return
 			}
 
-			if valid, errors := msg.Validate(); !valid {
-				FailedValidationResponse(r.Context(), conn, errors)
+			if !limiter.allow(ctx) {
 				return
 			}
 
-			topic, ok := h.topicMap[msg.Topic()]
-			if !ok {
-				ErrorResponse(
-					r.Context(),
-					conn,
-					http.StatusBadRequest,
-					fmt.Sprintf("topic '%s' doesn't exist", msg.Topic()),
-				)
+			amountSubscriptions := len(subscriptions)
+			if !h.handleMessage(r, conn, data, &subscriptions) {
 				return
 			}
 
-			err = authenticateOrigin(r, topic.allowedOrigins)
-			if err != nil {
-				ForbiddenResponse(r.Context(), conn)
+			// the strictest limits of the subscribed topics apply
+			if len(subscriptions) > amountSubscriptions {
+				topicLimits := []ConnectionLimits{}
+				for _, sub := range subscriptions {
+					if limits := sub.topic.limits(); limits != nil {
+						topicLimits = append(topicLimits, *limits)
+					}
+				}
+
+				if len(topicLimits) > 0 {
+					limiter.apply(strictestLimits(topicLimits))
+				}
 			}
+		}
+	}
+}
+
+// checkUpgrade verifies the origin of a request and runs the [UpgradeCheck].
+// If false is returned, an HTTP error response has been written.
+func (h WebSocketHandler[T]) checkUpgrade(
+	w http.ResponseWriter,
+	r *http.Request,
+) bool {
+	err := authenticateOrigin(r, h.options.originPatterns)
+	if err != nil {
+		contexttools.Logger(r.Context()).DebugContext(
+			r.Context(),
+			"rejected websocket upgrade",
+			logging.ErrAttr(err),
+		)
+		httptools.ForbiddenResponse(w, r)
+		return false
+	}
+
+	if h.options.upgradeCheck == nil {
+		return true
+	}
+
+	err = h.options.upgradeCheck(r)
+	if err != nil {
+		httptools.HandleError(w, r, err)
+		return false
+	}
+
+	return true
+}
+
+// ping pings a connection until it is closed. When a ping fails,
+// the connection is closed, which stops the read loop of the connection.
+func (h WebSocketHandler[T]) ping(ctx context.Context, conn *websocket.Conn) {
+	if h.pingInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(h.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pingCtx, cancel := context.WithTimeout(ctx, h.pingInterval)
+			err := conn.Ping(pingCtx)
+			cancel()
 
-			err = topic.Subscribe(conn)
 			if err != nil {
-				ServerErrorResponse(r.Context(), conn, err)
+				_ = conn.CloseNow()
 				return
 			}
 		}
 	}
 }
 
+// handleMessage handles a single message of a client.
+// If false is returned, no further messages should be handled.
+func (h WebSocketHandler[T]) handleMessage(
+	r *http.Request,
+	conn *websocket.Conn,
+	data []byte,
+	subscriptions *[]Subscriber,
+) bool {
+	var request RequestMessageDto
+	err := json.Unmarshal(data, &request)
+	if err == nil && request.Type != "" && request.RequestID != "" {
+		h.handleRequest(r.Context(), conn, request)
+		return true
+	}
+
+	var msg T
+	err = json.Unmarshal(data, &msg)
+	if err != nil {
+		ServerErrorResponse(
+			r.Context(),
+			conn,
+			fmt.Errorf("failed to unmarshal JSON: %w", err),
+		)
+		return false
+	}
+
+	sub, ok := h.handleSubscribe(r, conn, msg)
+	if ok {
+		*subscriptions = append(*subscriptions, sub)
+	}
+
+	return ok
+}
+
+func (h WebSocketHandler[T]) handleSubscribe(
+	r *http.Request,
+	conn *websocket.Conn,
+	msg T,
+) (Subscriber, bool) {
+	var sub Subscriber
+
+	if valid, errors := msg.Validate(); !valid {
+		FailedValidationResponse(r.Context(), conn, errors)
+		return sub, false
+	}
+
+	topic, ok := h.getTopic(msg.Topic())
+	if !ok {
+		topicNotFoundResponse(r.Context(), conn, msg.Topic())
+		return sub, false
+	}
+
+	// the origin patterns of the handler have been verified when upgrading
+	if len(topic.allowedOrigins) > 0 {
+		err := authenticateOrigin(r, topic.allowedOrigins)
+		if err != nil {
+			ForbiddenResponse(r.Context(), conn)
+			return sub, false
+		}
+	}
+
+	identity, err := h.identity(r, msg)
+	if err != nil {
+		errorDto := errorToErrorDto(r.Context(), err)
+		ErrorResponse(r.Context(), conn, errorDto.Status, errorDto.Message)
+		return sub, false
+	}
+
+	filter, err := h.filter(topic, msg)
+	if err != nil {
+		errorDto := errorToErrorDto(r.Context(), err)
+		ErrorResponse(r.Context(), conn, errorDto.Status, errorDto.Message)
+		return sub, false
+	}
+
+	//nolint:exhaustruct //other fields are optional
+	request := subscribeRequest{
+		identity: identity,
+		filter:   filter,
+	}
+
+	replayableMsg, ok := any(msg).(ReplayableSubscribeMessageDto)
+	if ok {
+		request.replay = replayableMsg.Replay()
+	}
+
+	sub, err = topic.subscribe(r.Context(), conn, request)
+	if errors.Is(err, errTopicRemoved) {
+		topicNotFoundResponse(r.Context(), conn, msg.Topic())
+		return sub, false
+	}
+
+	if err != nil {
+		ServerErrorResponse(r.Context(), conn, err)
+		topic.UnSubscribe(sub)
+		return sub, false
+	}
+
+	return sub, true
+}
+
+// identity resolves the identity of a client subscribing to a topic.
+func (h WebSocketHandler[T]) identity(r *http.Request, msg T) (string, error) {
+	if h.identityFunc != nil {
+		return h.identityFunc(r)
+	}
+
+	identifiedMsg, ok := any(msg).(IdentifiedSubscribeMessageDto)
+	if ok {
+		return identifiedMsg.Identity(), nil
+	}
+
+	return "", nil
+}
+
+// filter creates the filter of a client subscribing to a topic.
+func (h WebSocketHandler[T]) filter(topic *Topic, msg T) (eventFilter, error) {
+	filteredMsg, ok := any(msg).(FilteredSubscribeMessageDto)
+	if !ok {
+		//nolint:nilnil //without filter all events are sent
+		return nil, nil
+	}
+
+	return topic.buildFilter(filteredMsg.Filter())
+}
+
+func topicNotFoundResponse(
+	ctx context.Context,
+	conn *websocket.Conn,
+	topicName string,
+) {
+	ErrorResponse(
+		ctx,
+		conn,
+		http.StatusBadRequest,
+		fmt.Sprintf("topic '%s' doesn't exist", topicName),
+	)
+}
+
 // copied from github.com/coder/websocket.
 func authenticateOrigin(r *http.Request, originHosts []string) error {
 	origin := r.Header.Get("Origin")
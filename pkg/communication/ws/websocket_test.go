@@ -2,12 +2,16 @@ package ws_test
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 
 	wstools "github.com/XDoubleU/essentia/pkg/communication/ws"
 	errortools "github.com/XDoubleU/essentia/pkg/errors"
 	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/metrics"
 	"github.com/XDoubleU/essentia/pkg/test"
 	"github.com/XDoubleU/essentia/pkg/validate"
 	"github.com/stretchr/testify/assert"
@@ -185,3 +189,103 @@ func TestWebSocketRemoveNonExistingTopic(t *testing.T) {
 	})
 	assert.ErrorContains(t, err, "topic 'unknown' doesn't exist")
 }
+
+func TestWebSocketRemoveTopicNotifiesSubscribers(t *testing.T) {
+	logger := logging.NewNopLogger()
+
+	ws := wstools.CreateWebSocketHandler[TestSubscribeMsg](logger, 1, 10)
+	topic, err := ws.AddTopic(
+		"exists",
+		[]string{"http://localhost"},
+		func(_ context.Context, _ *wstools.Topic) (any, error) {
+			return TestResponse{Ok: true}, nil
+		},
+	)
+	require.Nil(t, err)
+
+	ts := httptest.NewServer(ws.Handler())
+	defer ts.Close()
+
+	conn := dialAndSubscribe(t, ts, TestSubscribeMsg{TopicName: "exists"})
+	assert.True(t, readMessage[TestResponse](t, conn).Ok)
+
+	assert.Equal(t, []wstools.TopicInfo{
+		{Name: "exists", Subscribers: 1},
+	}, ws.Topics())
+
+	err = ws.RemoveTopic(topic)
+	require.Nil(t, err)
+
+	errorDto := readMessage[errortools.ErrorDto](t, conn)
+	assert.Equal(t, http.StatusGone, errorDto.Status)
+	assert.Equal(t, "topic 'exists' has been removed", errorDto.Message)
+
+	assert.Equal(t, []wstools.TopicInfo{}, ws.Topics())
+	assert.Equal(t, 0, topic.SubscriberCount())
+
+	// events of removed topics are dropped
+	topic.EnqueueEvent(TestResponse{Ok: true})
+}
+
+func TestWebSocketConcurrentTopicManagement(t *testing.T) {
+	logger := logging.NewNopLogger()
+
+	ws := wstools.CreateWebSocketHandler[TestSubscribeMsg](logger, 1, 10)
+	ts := httptest.NewServer(ws.Handler())
+	defer ts.Close()
+
+	wg := sync.WaitGroup{}
+	for i := range 10 {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			name := fmt.Sprintf("topic%d", i)
+			topic, err := ws.AddTopic(name, []string{"http://localhost"}, nil)
+			assert.Nil(t, err)
+
+			topic, err = ws.UpdateTopicName(topic, name+"-renamed")
+			assert.Nil(t, err)
+
+			_ = ws.Topics()
+
+			err = ws.RemoveTopic(topic)
+			assert.Nil(t, err)
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			tWeb := test.CreateWebSocketTester(ws.Handler())
+			tWeb.SetInitialMessage(TestSubscribeMsg{
+				TopicName: fmt.Sprintf("topic%d", i),
+			})
+
+			var response errortools.ErrorDto
+			_ = tWeb.Do(t, &response, nil)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, []wstools.TopicInfo{}, ws.Topics())
+}
+
+func TestWebSocketMetrics(t *testing.T) {
+	ws := wstools.CreateWebSocketHandler[TestSubscribeMsg](
+		logging.NewNopLogger(),
+		1,
+		10,
+	)
+	registry := metrics.NewRegistry()
+	ws.RegisterMetrics(registry)
+
+	_, err := ws.AddTopic("topic", []string{"http://localhost"}, nil)
+	require.Nil(t, err)
+
+	rr := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Contains(t, rr.Body.String(), `ws_topic_subscribers{topic="topic"} 0`)
+}
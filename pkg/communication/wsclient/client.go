@@ -0,0 +1,300 @@
+package wsclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	wstools "github.com/XDoubleU/essentia/pkg/communication/ws"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+// Event is received by a [Client], either Data or Err is set.
+// Topic, Epoch and Sequence are only set for events
+// of topics with history enabled.
+type Event[E any] struct {
+	Topic    string
+	Epoch    string
+	Sequence uint64
+	Data     E
+	Err      error
+}
+
+// ServerError is the error described by a [wstools.ErrorMessageDto]
+// sent by the server.
+type ServerError struct {
+	Status  int
+	Message any
+}
+
+func (err ServerError) Error() string {
+	return fmt.Sprintf(
+		"server responded with %d %s: %v",
+		err.Status,
+		http.StatusText(err.Status),
+		err.Message,
+	)
+}
+
+// ResumableSubscribeMessage can be implemented by subscribe messages of
+// topics with history enabled. After reconnecting, the message returned
+// by Resume is sent instead, so the server replays the missed events.
+// The server only knows the sequences of its own epoch, so resuming
+// after reconnecting to another instance is rejected by the server.
+type ResumableSubscribeMessage interface {
+	Topic() string
+	Resume(epoch string, sinceSequence uint64) any
+}
+
+// Client consumes the topics of a WebSocketHandler. When the connection
+// is lost, it reconnects with exponential backoff and resubscribes.
+// Received events are delivered on the channel returned by [Client.Events].
+type Client[E any] struct {
+	logger        *slog.Logger
+	url           string
+	dialOptions   *websocket.DialOptions
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	mu            *sync.Mutex
+	conn          *websocket.Conn
+	subscriptions []any
+	positions     map[string]position
+	events        chan Event[E]
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+// position is the last event of a topic received by a [Client].
+type position struct {
+	epoch    string
+	sequence uint64
+}
+
+// frame contains the fields used to tell the messages of a server apart.
+type frame struct {
+	Type     string          `json:"type"`
+	Topic    string          `json:"topic"`
+	Epoch    string          `json:"epoch"`
+	Sequence uint64          `json:"sequence"`
+	Event    json.RawMessage `json:"event"`
+	Status   int             `json:"status"`
+	Error    string          `json:"error"`
+	Message  any             `json:"message"`
+}
+
+// NewClient creates a new [Client] for the provided url. Reconnecting
+// is first retried after minBackoff, which doubles up to maxBackoff
+// for every failed attempt. Call [Client.Start] to connect.
+func NewClient[E any](
+	logger *slog.Logger,
+	url string,
+	minBackoff time.Duration,
+	maxBackoff time.Duration,
+	bufferSize int,
+) *Client[E] {
+	return &Client[E]{
+		logger:        logger,
+		url:           url,
+		dialOptions:   nil,
+		minBackoff:    minBackoff,
+		maxBackoff:    maxBackoff,
+		mu:            &sync.Mutex{},
+		conn:          nil,
+		subscriptions: []any{},
+		positions:     make(map[string]position),
+		events:        make(chan Event[E], bufferSize),
+		cancel:        nil,
+		done:          make(chan struct{}),
+	}
+}
+
+// SetDialOptions sets the options used when dialing the server,
+// for example to provide headers used for authentication.
+// This should be called before [Client.Start].
+func (c *Client[E]) SetDialOptions(dialOptions *websocket.DialOptions) {
+	c.dialOptions = dialOptions
+}
+
+// Start connects to the server in the background.
+func (c *Client[E]) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	go c.run(ctx)
+}
+
+// Close closes the connection and stops reconnecting.
+// Afterwards the channel returned by [Client.Events] is closed.
+func (c *Client[E]) Close() {
+	if c.cancel == nil {
+		return
+	}
+
+	c.cancel()
+	<-c.done
+}
+
+// Events returns the channel on which received events are delivered.
+func (c *Client[E]) Events() <-chan Event[E] {
+	return c.events
+}
+
+// Subscribe sends a subscribe message to the server when connected.
+// The message is sent again after every reconnect,
+// even if sending it now returns an error.
+func (c *Client[E]) Subscribe(ctx context.Context, msg any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.subscriptions = append(c.subscriptions, msg)
+
+	if c.conn == nil {
+		return nil
+	}
+
+	return wsjson.Write(ctx, c.conn, msg)
+}
+
+func (c *Client[E]) run(ctx context.Context) {
+	defer close(c.done)
+	defer close(c.events)
+
+	backoff := c.minBackoff
+	for {
+		received, err := c.connectAndRead(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if received {
+			backoff = c.minBackoff
+		}
+
+		c.logger.Warn(
+			"lost connection of websocket client",
+			logging.ErrAttr(err),
+			slog.String("url", c.url),
+			slog.String("retry_in", backoff.String()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, c.maxBackoff)
+	}
+}
+
+// connectAndRead connects, resubscribes and delivers events until
+// the connection is lost. Returns true if any message was received.
+func (c *Client[E]) connectAndRead(ctx context.Context) (bool, error) {
+	conn, _, err := websocket.Dial(ctx, c.url, c.dialOptions)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = conn.CloseNow() }()
+
+	err = c.resubscribe(ctx, conn)
+	if err != nil {
+		return false, err
+	}
+
+	defer c.setConn(nil)
+
+	received := false
+	for {
+		var data []byte
+		_, data, err = conn.Read(ctx)
+		if err != nil {
+			return received, err
+		}
+
+		received = true
+		if !c.deliver(ctx, c.decode(data)) {
+			return received, ctx.Err()
+		}
+	}
+}
+
+// resubscribe sends all subscribe messages over a new connection.
+// Subscriptions of topics with history resume from the last seen sequence.
+func (c *Client[E]) resubscribe(ctx context.Context, conn *websocket.Conn) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, msg := range c.subscriptions {
+		resumable, ok := msg.(ResumableSubscribeMessage)
+		if ok {
+			last, seen := c.positions[resumable.Topic()]
+			if seen {
+				msg = resumable.Resume(last.epoch, last.sequence)
+			}
+		}
+
+		err := wsjson.Write(ctx, conn, msg)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.conn = conn
+	return nil
+}
+
+func (c *Client[E]) setConn(conn *websocket.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.conn = conn
+}
+
+// decode turns a message of the server into an [Event].
+func (c *Client[E]) decode(data []byte) Event[E] {
+	//nolint:exhaustruct //other fields are optional
+	event := Event[E]{}
+
+	var f frame
+	err := json.Unmarshal(data, &f)
+	if err == nil && f.Type == wstools.ErrorMessageType {
+		event.Err = ServerError{
+			Status:  f.Status,
+			Message: f.Message,
+		}
+		return event
+	}
+
+	if err == nil && f.Sequence != 0 && f.Event != nil {
+		event.Topic = f.Topic
+		event.Epoch = f.Epoch
+		event.Sequence = f.Sequence
+		data = f.Event
+
+		c.mu.Lock()
+		c.positions[f.Topic] = position{epoch: f.Epoch, sequence: f.Sequence}
+		c.mu.Unlock()
+	}
+
+	err = json.Unmarshal(data, &event.Data)
+	if err != nil {
+		event.Err = fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	return event
+}
+
+func (c *Client[E]) deliver(ctx context.Context, event Event[E]) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case c.events <- event:
+		return true
+	}
+}
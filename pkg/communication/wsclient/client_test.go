@@ -0,0 +1,252 @@
+package wsclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	wstools "github.com/XDoubleU/essentia/pkg/communication/ws"
+	"github.com/XDoubleU/essentia/pkg/communication/wsclient"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type SubscribeMsg struct {
+	TopicName     string  `json:"topicName"`
+	SinceSequence *uint64 `json:"sinceSequence"`
+	Epoch         string  `json:"epoch"`
+}
+
+func (s SubscribeMsg) Validate() (bool, map[string]string) {
+	v := validate.New()
+
+	validate.Check(v, "topicName", s.TopicName, validate.IsNotEmpty)
+
+	return v.Valid(), v.Errors()
+}
+
+func (s SubscribeMsg) Topic() string {
+	return s.TopicName
+}
+
+func (s SubscribeMsg) Replay() *wstools.ReplayRequest {
+	if s.SinceSequence == nil {
+		return nil
+	}
+
+	return &wstools.ReplayRequest{
+		SinceSequence: s.SinceSequence,
+		Epoch:         s.Epoch,
+		LastEvents:    0,
+	}
+}
+
+func (s SubscribeMsg) Resume(epoch string, sinceSequence uint64) any {
+	s.SinceSequence = &sinceSequence
+	s.Epoch = epoch
+	return s
+}
+
+// server allows dropping all connections of a [wstools.WebSocketHandler].
+type server struct {
+	mu      *sync.Mutex
+	cancels []context.CancelFunc
+	handler http.Handler
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+
+	s.mu.Lock()
+	s.cancels = append(s.cancels, cancel)
+	s.mu.Unlock()
+
+	s.handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func (s *server) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, cancel := range s.cancels {
+		cancel()
+	}
+	s.cancels = nil
+}
+
+func setup(t *testing.T) (*wstools.Topic, *server, *wsclient.Client[int]) {
+	t.Helper()
+
+	ws := wstools.CreateWebSocketHandler[SubscribeMsg](
+		logging.NewNopLogger(),
+		1,
+		10,
+	)
+
+	topic, err := ws.AddTopic("events", []string{}, nil)
+	require.Nil(t, err)
+	topic.EnableHistory(10)
+
+	srv := &server{
+		mu:      &sync.Mutex{},
+		cancels: nil,
+		handler: ws.Handler(),
+	}
+
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	client := wsclient.NewClient[int](
+		logging.NewNopLogger(),
+		"ws"+strings.TrimPrefix(ts.URL, "http"),
+		10*time.Millisecond,
+		100*time.Millisecond,
+		10,
+	)
+	client.Start()
+	t.Cleanup(client.Close)
+
+	return topic, srv, client
+}
+
+func waitForSubscribers(t *testing.T, topic *wstools.Topic, amount int) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		return topic.SubscriberCount() == amount
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func receive[E any](t *testing.T, client *wsclient.Client[E]) wsclient.Event[E] {
+	t.Helper()
+
+	select {
+	case event := <-client.Events():
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		//nolint:exhaustruct //unreachable
+		return wsclient.Event[E]{}
+	}
+}
+
+func TestClientReceivesEvents(t *testing.T) {
+	topic, _, client := setup(t)
+
+	err := client.Subscribe(
+		context.Background(),
+		SubscribeMsg{TopicName: "events", SinceSequence: nil, Epoch: ""},
+	)
+	require.Nil(t, err)
+	waitForSubscribers(t, topic, 1)
+
+	topic.EnqueueEvent(1)
+	topic.EnqueueEvent(2)
+
+	for _, expected := range []int{1, 2} {
+		event := receive(t, client)
+		require.Nil(t, event.Err)
+		assert.Equal(t, "events", event.Topic)
+		//nolint:gosec //expected is small
+		assert.Equal(t, uint64(expected), event.Sequence)
+		assert.Equal(t, expected, event.Data)
+	}
+}
+
+func TestClientResumesAfterReconnect(t *testing.T) {
+	topic, srv, client := setup(t)
+
+	err := client.Subscribe(
+		context.Background(),
+		SubscribeMsg{TopicName: "events", SinceSequence: nil, Epoch: ""},
+	)
+	require.Nil(t, err)
+	waitForSubscribers(t, topic, 1)
+
+	topic.EnqueueEvent(1)
+	assert.Equal(t, 1, receive(t, client).Data)
+
+	srv.dropConnections()
+	waitForSubscribers(t, topic, 0)
+
+	// missed while disconnected
+	topic.EnqueueEvent(2)
+	topic.EnqueueEvent(3)
+
+	for _, expected := range []int{2, 3} {
+		event := receive(t, client)
+		require.Nil(t, event.Err)
+		assert.Equal(t, expected, event.Data)
+	}
+
+	waitForSubscribers(t, topic, 1)
+	topic.EnqueueEvent(4)
+	assert.Equal(t, 4, receive(t, client).Data)
+}
+
+func TestClientServerError(t *testing.T) {
+	_, _, client := setup(t)
+
+	err := client.Subscribe(
+		context.Background(),
+		SubscribeMsg{TopicName: "unknown", SinceSequence: nil, Epoch: ""},
+	)
+	require.Nil(t, err)
+
+	event := receive(t, client)
+
+	var serverError wsclient.ServerError
+	require.True(t, errors.As(event.Err, &serverError))
+	assert.Equal(t, http.StatusBadRequest, serverError.Status)
+	assert.Equal(t, "topic 'unknown' doesn't exist", serverError.Message)
+}
+
+type statusEvent struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
+func TestClientEventWithErrorFields(t *testing.T) {
+	ws := wstools.CreateWebSocketHandler[SubscribeMsg](
+		logging.NewNopLogger(),
+		1,
+		10,
+	)
+
+	topic, err := ws.AddTopic("events", []string{}, nil)
+	require.Nil(t, err)
+
+	ts := httptest.NewServer(ws.Handler())
+	t.Cleanup(ts.Close)
+
+	client := wsclient.NewClient[statusEvent](
+		logging.NewNopLogger(),
+		"ws"+strings.TrimPrefix(ts.URL, "http"),
+		10*time.Millisecond,
+		100*time.Millisecond,
+		10,
+	)
+	client.Start()
+	t.Cleanup(client.Close)
+
+	err = client.Subscribe(
+		context.Background(),
+		SubscribeMsg{TopicName: "events", SinceSequence: nil, Epoch: ""},
+	)
+	require.Nil(t, err)
+	waitForSubscribers(t, topic, 1)
+
+	expected := statusEvent{Status: http.StatusInternalServerError, Error: "failed"}
+	topic.EnqueueEvent(expected)
+
+	event := receive(t, client)
+	require.Nil(t, event.Err)
+	assert.Equal(t, expected, event.Data)
+}
@@ -0,0 +1,3 @@
+// Package wsclient contains a client for consuming the topics of a
+// WebSocketHandler, which reconnects and resubscribes automatically.
+package wsclient
@@ -2,3 +2,6 @@ package context
 
 const showErrorsContextKey = Key("show_errors")
 const loggerContextKey = Key("logger")
+const clientIPContextKey = Key("client_ip")
+const requestIDContextKey = Key("request_id")
+const claimsContextKey = Key("claims")
@@ -60,3 +60,48 @@ func ShowErrors(ctx context.Context) bool {
 
 	return *showErrors
 }
+
+// WithClientIP sets the IP of the client of a request on the context.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPContextKey, ip)
+}
+
+// ClientIP returns the IP of the client of a request stored
+// in the context or an empty string.
+func ClientIP(ctx context.Context) string {
+	ip := GetValue[string](ctx, clientIPContextKey)
+
+	if ip == nil {
+		return ""
+	}
+
+	return *ip
+}
+
+// WithRequestID sets the ID of a request on the context.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, id)
+}
+
+// RequestID returns the ID of a request stored
+// in the context or an empty string.
+func RequestID(ctx context.Context) string {
+	id := GetValue[string](ctx, requestIDContextKey)
+
+	if id == nil {
+		return ""
+	}
+
+	return *id
+}
+
+// WithClaims sets the claims of the authenticated user on the context.
+func WithClaims(ctx context.Context, claims any) context.Context {
+	return context.WithValue(ctx, claimsContextKey, claims)
+}
+
+// Claims returns the claims of the authenticated user stored in
+// the context or nil when these are missing or not of type T.
+func Claims[T any](ctx context.Context) *T {
+	return GetValue[T](ctx, claimsContextKey)
+}
@@ -60,3 +60,25 @@ func TestGetNullLogger(t *testing.T) {
 
 	assert.Equal(t, logging.NewNopLogger(), value)
 }
+
+func TestSetGetClientIP(t *testing.T) {
+	ctx := contexttools.WithClientIP(context.Background(), "10.0.0.1")
+
+	assert.Equal(t, "10.0.0.1", contexttools.ClientIP(ctx))
+	assert.Equal(t, "", contexttools.ClientIP(context.Background()))
+}
+
+func TestSetGetRequestID(t *testing.T) {
+	ctx := contexttools.WithRequestID(context.Background(), "id")
+
+	assert.Equal(t, "id", contexttools.RequestID(ctx))
+	assert.Equal(t, "", contexttools.RequestID(context.Background()))
+}
+
+func TestSetGetClaims(t *testing.T) {
+	ctx := contexttools.WithClaims(context.Background(), "claims")
+
+	assert.Equal(t, "claims", *contexttools.Claims[string](ctx))
+	assert.Nil(t, contexttools.Claims[int](ctx))
+	assert.Nil(t, contexttools.Claims[string](context.Background()))
+}
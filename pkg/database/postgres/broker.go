@@ -0,0 +1,325 @@
+package postgres
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxNotifyPayloadSize is the maximum size of a chunk sent through NOTIFY.
+// Postgres limits payloads to 8000 bytes, some room is kept for the header.
+const maxNotifyPayloadSize = 7900
+
+// maxChunkAge is the time after which incomplete messages are discarded.
+const maxChunkAge = time.Minute
+
+// handlerQueueSize is the amount of payloads waiting on the handlers,
+// after which payloads are dropped instead of blocking the listener.
+const handlerQueueSize = 1024
+
+// Broker distributes payloads between multiple instances
+// of an application using LISTEN/NOTIFY of postgres.
+// It can be used as [ws.Broker].
+//
+// Payloads which are too large for a single NOTIFY are split up in chunks
+// which are sent in one transaction and combined again by the listeners.
+// Handlers are called in order by a separate go routine, so slow handlers
+// don't stop the listener from receiving notifications.
+type Broker struct {
+	logger         *slog.Logger
+	pool           *pgxpool.Pool
+	channel        string
+	reconnectDelay time.Duration
+	handlers       []func(topic string, payload []byte)
+	handlersMu     *sync.RWMutex
+	payloads       chan receivedPayload
+	chunks         map[string]*chunkedMessage
+	cancel         context.CancelFunc
+}
+
+type receivedPayload struct {
+	topic   string
+	payload []byte
+}
+
+type chunkedMessage struct {
+	parts    []string
+	received int
+	created  time.Time
+}
+
+// NewBroker creates a new [Broker] which publishes and listens on the provided
+// channel. When the listening connection drops, a new connection is acquired
+// from the pool after reconnectDelay.
+func NewBroker(
+	logger *slog.Logger,
+	pool *pgxpool.Pool,
+	channel string,
+	reconnectDelay time.Duration,
+) *Broker {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	broker := &Broker{
+		logger:         logger,
+		pool:           pool,
+		channel:        channel,
+		reconnectDelay: reconnectDelay,
+		handlers:       []func(topic string, payload []byte){},
+		handlersMu:     &sync.RWMutex{},
+		payloads:       make(chan receivedPayload, handlerQueueSize),
+		chunks:         make(map[string]*chunkedMessage),
+		cancel:         cancel,
+	}
+
+	go broker.listen(ctx)
+	go broker.dispatch(ctx)
+
+	return broker
+}
+
+// Publish sends a payload of a topic to all listening instances.
+func (b *Broker) Publish(ctx context.Context, topic string, payload []byte) error {
+	chunks := splitPayload(topic, payload)
+
+	tx, err := b.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	//nolint:errcheck //rollback after commit is a no-op
+	defer tx.Rollback(ctx)
+
+	for _, chunk := range chunks {
+		_, err = tx.Exec(ctx, "SELECT pg_notify($1, $2)", b.channel, chunk)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Listen registers a handler which is called
+// for every payload published by any instance.
+// Payloads are dropped when handlers fall behind too far.
+func (b *Broker) Listen(handler func(topic string, payload []byte)) {
+	b.handlersMu.Lock()
+	defer b.handlersMu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
+
+// Close stops listening for new payloads.
+func (b *Broker) Close() {
+	b.cancel()
+}
+
+func (b *Broker) listen(ctx context.Context) {
+	for ctx.Err() == nil {
+		err := b.listenOnConn(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		b.logger.Warn(
+			"lost connection of broker listener",
+			logging.ErrAttr(err),
+			slog.String("retry_in", b.reconnectDelay.String()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(b.reconnectDelay):
+		}
+	}
+}
+
+func (b *Broker) listenOnConn(ctx context.Context) error {
+	conn, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the connection is closed as it still listens on the channel
+	pgxConn := conn.Hijack()
+	defer pgxConn.Close(context.Background())
+
+	_, err = pgxConn.Exec(ctx, "LISTEN "+pgx.Identifier{b.channel}.Sanitize())
+	if err != nil {
+		return err
+	}
+
+	b.logger.Debug("broker listening", slog.String("channel", b.channel))
+
+	for {
+		notification, err := pgxConn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		b.onNotification(notification.Payload)
+	}
+}
+
+func (b *Broker) onNotification(notification string) {
+	message, err := b.combineChunks(notification)
+	if err != nil {
+		b.logger.Error("received invalid notification", logging.ErrAttr(err))
+		return
+	}
+
+	if message == nil {
+		return
+	}
+
+	topic, payload, err := decodeMessage(*message)
+	if err != nil {
+		b.logger.Error("received invalid notification", logging.ErrAttr(err))
+		return
+	}
+
+	select {
+	case b.payloads <- receivedPayload{topic: topic, payload: payload}:
+	default:
+		b.logger.Error(
+			"dropped payload as broker handlers are too slow",
+			slog.String("topic", topic),
+		)
+	}
+}
+
+// dispatch passes the received payloads to the handlers.
+func (b *Broker) dispatch(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case received := <-b.payloads:
+			b.handlersMu.RLock()
+			for _, handler := range b.handlers {
+				handler(received.topic, received.payload)
+			}
+			b.handlersMu.RUnlock()
+		}
+	}
+}
+
+// combineChunks stores a chunk and returns the complete
+// message once all chunks of it have been received.
+// Only the listening go routine uses this, so no locking is needed.
+func (b *Broker) combineChunks(notification string) (*string, error) {
+	//nolint:mnd //id, index, total and data
+	header := strings.SplitN(notification, ":", 4)
+	//nolint:mnd //id, index, total and data
+	if len(header) != 4 {
+		return nil, errors.New("missing chunk header")
+	}
+
+	id, data := header[0], header[3]
+
+	index, err := strconv.Atoi(header[1])
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := strconv.Atoi(header[2])
+	if err != nil {
+		return nil, err
+	}
+
+	if index < 0 || total <= 0 || index >= total {
+		return nil, fmt.Errorf("invalid chunk %d of %d", index, total)
+	}
+
+	if total == 1 {
+		return &data, nil
+	}
+
+	for key, message := range b.chunks {
+		if time.Since(message.created) > maxChunkAge {
+			delete(b.chunks, key)
+		}
+	}
+
+	message, ok := b.chunks[id]
+	if !ok {
+		message = &chunkedMessage{
+			parts:    make([]string, total),
+			received: 0,
+			created:  time.Now(),
+		}
+		b.chunks[id] = message
+	}
+
+	if message.parts[index] == "" {
+		message.received++
+	}
+	message.parts[index] = data
+
+	if message.received < total {
+		return nil, nil //nolint:nilnil //message isn't complete yet
+	}
+
+	delete(b.chunks, id)
+
+	result := strings.Join(message.parts, "")
+	return &result, nil
+}
+
+// splitPayload encodes a payload with its topic and splits it up in chunks.
+// Each chunk has the format "id:index:total:data".
+func splitPayload(topic string, payload []byte) []string {
+	message := fmt.Sprintf(
+		"%s:%s",
+		base64.StdEncoding.EncodeToString([]byte(topic)),
+		base64.StdEncoding.EncodeToString(payload),
+	)
+
+	id := uuid.NewString()
+	total := (len(message) + maxNotifyPayloadSize - 1) / maxNotifyPayloadSize
+
+	chunks := make([]string, 0, total)
+	for i := 0; i < total; i++ {
+		end := min((i+1)*maxNotifyPayloadSize, len(message))
+		chunks = append(chunks, fmt.Sprintf(
+			"%s:%d:%d:%s",
+			id,
+			i,
+			total,
+			message[i*maxNotifyPayloadSize:end],
+		))
+	}
+
+	return chunks
+}
+
+func decodeMessage(message string) (string, []byte, error) {
+	encodedTopic, encodedPayload, ok := strings.Cut(message, ":")
+	if !ok {
+		return "", nil, errors.New("missing topic")
+	}
+
+	topic, err := base64.StdEncoding.DecodeString(encodedTopic)
+	if err != nil {
+		return "", nil, err
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return string(topic), payload, nil
+}
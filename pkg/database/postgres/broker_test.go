@@ -0,0 +1,66 @@
+package postgres_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	wstools "github.com/XDoubleU/essentia/pkg/communication/ws"
+	"github.com/XDoubleU/essentia/pkg/config"
+	"github.com/XDoubleU/essentia/pkg/database/postgres"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ wstools.Broker = &postgres.Broker{}
+
+type brokerMessage struct {
+	topic   string
+	payload []byte
+}
+
+func TestBroker(t *testing.T) {
+	logger := logging.NewNopLogger()
+	dsn := config.New(logger).EnvStr("DB_DSN", "postgres://postgres@localhost/postgres")
+
+	pool, err := postgres.Connect(logger, dsn, 5, "1m", 5, time.Second, 5*time.Second)
+	require.Nil(t, err)
+	defer pool.Close()
+
+	publisher := postgres.NewBroker(logger, pool, "essentia_test", time.Second)
+	defer publisher.Close()
+
+	listener := postgres.NewBroker(logger, pool, "essentia_test", time.Second)
+	defer listener.Close()
+
+	received := make(chan brokerMessage, 2)
+	listener.Listen(func(topic string, payload []byte) {
+		received <- brokerMessage{topic: topic, payload: payload}
+	})
+
+	// wait until the listener is connected
+	time.Sleep(500 * time.Millisecond)
+
+	largePayload := []byte(strings.Repeat("a", 20000))
+
+	err = publisher.Publish(context.Background(), "small", []byte("test"))
+	require.Nil(t, err)
+
+	err = publisher.Publish(context.Background(), "large", largePayload)
+	require.Nil(t, err)
+
+	for _, expected := range []brokerMessage{
+		{topic: "small", payload: []byte("test")},
+		{topic: "large", payload: largePayload},
+	} {
+		select {
+		case message := <-received:
+			assert.Equal(t, expected.topic, message.topic)
+			assert.Equal(t, expected.payload, message.payload)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("didn't receive payload of topic '%s'", expected.topic)
+		}
+	}
+}
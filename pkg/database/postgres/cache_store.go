@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/XDoubleU/essentia/pkg/stores"
+	"github.com/jackc/pgx/v5"
+)
+
+// CacheStore is a [stores.CacheStore] storing responses in a postgres
+// table, so multiple instances of an application share their cache.
+// It can be added to a [threading.JobQueue] to remove expired responses.
+type CacheStore struct {
+	db              DB
+	table           string
+	cleanupInterval time.Duration
+}
+
+// NewCacheStore creates a new [CacheStore] using the provided table,
+// which can be created using [CacheStore.CreateTable]. When used as job,
+// expired responses are removed every cleanupInterval.
+func NewCacheStore(
+	db DB,
+	table string,
+	cleanupInterval time.Duration,
+) *CacheStore {
+	return &CacheStore{
+		db:              db,
+		table:           pgx.Identifier{table}.Sanitize(),
+		cleanupInterval: cleanupInterval,
+	}
+}
+
+// CreateTable creates the table of a [CacheStore] if it doesn't exist.
+func (s *CacheStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.Exec(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			key TEXT PRIMARY KEY,
+			status INTEGER NOT NULL,
+			header JSONB NOT NULL,
+			body BYTEA NOT NULL,
+			tags TEXT[] NOT NULL,
+			stored_at TIMESTAMPTZ NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL
+		)`, s.table))
+	return err
+}
+
+// Get returns a stored response.
+func (s *CacheStore) Get(
+	ctx context.Context,
+	key string,
+) (*stores.CachedResponse, error) {
+	var response stores.CachedResponse
+
+	var header []byte
+	err := s.db.QueryRow(ctx, fmt.Sprintf(`
+		SELECT status, header, body, tags, stored_at, expires_at
+		FROM %s
+		WHERE key = $1 AND expires_at > now()`, s.table),
+		key,
+	).Scan(
+		&response.Status,
+		&header,
+		&response.Body,
+		&response.Tags,
+		&response.StoredAt,
+		&response.ExpiresAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		//nolint:nilnil //a missing response isn't an error
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(header, &response.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// Set stores a response.
+func (s *CacheStore) Set(
+	ctx context.Context,
+	key string,
+	response stores.CachedResponse,
+) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	tags := response.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	_, err = s.db.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO %s (key, status, header, body, tags, stored_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (key) DO UPDATE
+		SET status = $2, header = $3, body = $4, tags = $5,
+			stored_at = $6, expires_at = $7`, s.table),
+		key,
+		response.Status,
+		header,
+		response.Body,
+		tags,
+		response.StoredAt,
+		response.ExpiresAt,
+	)
+	return err
+}
+
+// InvalidateTags removes all responses having any of the provided tags.
+func (s *CacheStore) InvalidateTags(ctx context.Context, tags ...string) error {
+	_, err := s.db.Exec(
+		ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE tags && $1", s.table),
+		tags,
+	)
+	return err
+}
+
+// ID returns the id of the cleanup job of a [CacheStore].
+func (s *CacheStore) ID() string {
+	return "cache-cleanup-" + s.table
+}
+
+// Run removes the expired responses.
+func (s *CacheStore) Run(ctx context.Context, logger *slog.Logger) error {
+	tag, err := s.db.Exec(
+		ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE expires_at <= now()", s.table),
+	)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug(
+		"removed expired cached responses",
+		slog.Int64("amount", tag.RowsAffected()),
+	)
+	return nil
+}
+
+// RunEvery returns the interval of the cleanup job of a [CacheStore].
+func (s *CacheStore) RunEvery() time.Duration {
+	return s.cleanupInterval
+}
@@ -0,0 +1,74 @@
+package postgres_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/XDoubleU/essentia/pkg/config"
+	"github.com/XDoubleU/essentia/pkg/database/postgres"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/stores"
+	"github.com/XDoubleU/essentia/pkg/threading"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ stores.CacheStore = &postgres.CacheStore{}
+	_ threading.Job     = &postgres.CacheStore{}
+)
+
+func TestCacheStore(t *testing.T) {
+	logger := logging.NewNopLogger()
+	dsn := config.New(logger).EnvStr("DB_DSN", "postgres://postgres@localhost/postgres")
+
+	pool, err := postgres.Connect(logger, dsn, 5, "1m", 5, time.Second, 5*time.Second)
+	require.Nil(t, err)
+	defer pool.Close()
+
+	ctx := context.Background()
+	store := postgres.NewCacheStore(pool, "essentia_cache_test", time.Minute)
+
+	err = store.CreateTable(ctx)
+	require.Nil(t, err)
+
+	now := time.Now()
+	response := stores.CachedResponse{
+		Status:    http.StatusOK,
+		Header:    http.Header{"Etag": []string{`"test"`}},
+		Body:      []byte("test"),
+		Tags:      []string{"users"},
+		StoredAt:  now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+
+	err = store.Set(ctx, "key", response)
+	require.Nil(t, err)
+
+	loaded, err := store.Get(ctx, "key")
+	require.Nil(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, response.Header, loaded.Header)
+	assert.Equal(t, response.Body, loaded.Body)
+	assert.Equal(t, response.Tags, loaded.Tags)
+
+	err = store.InvalidateTags(ctx, "posts", "users")
+	require.Nil(t, err)
+
+	loaded, err = store.Get(ctx, "key")
+	require.Nil(t, err)
+	assert.Nil(t, loaded)
+
+	response.ExpiresAt = now.Add(-time.Hour)
+	err = store.Set(ctx, "key", response)
+	require.Nil(t, err)
+
+	loaded, err = store.Get(ctx, "key")
+	require.Nil(t, err)
+	assert.Nil(t, loaded)
+
+	err = store.Run(ctx, logger)
+	require.Nil(t, err)
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"github.com/XDoubleU/essentia/pkg/metrics"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type poolStat struct {
+	name    string
+	help    string
+	counter bool
+	value   func(stat *pgxpool.Stat) float64
+}
+
+//nolint:gochecknoglobals //used as constant
+var poolStats = []poolStat{
+	{
+		name:    "pgx_pool_acquired_conns",
+		help:    "Amount of currently acquired connections.",
+		counter: false,
+		value:   func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) },
+	},
+	{
+		name:    "pgx_pool_idle_conns",
+		help:    "Amount of currently idle connections.",
+		counter: false,
+		value:   func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) },
+	},
+	{
+		name:    "pgx_pool_constructing_conns",
+		help:    "Amount of connections being constructed.",
+		counter: false,
+		value:   func(s *pgxpool.Stat) float64 { return float64(s.ConstructingConns()) },
+	},
+	{
+		name:    "pgx_pool_total_conns",
+		help:    "Total amount of connections.",
+		counter: false,
+		value:   func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) },
+	},
+	{
+		name:    "pgx_pool_max_conns",
+		help:    "Maximum amount of connections.",
+		counter: false,
+		value:   func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) },
+	},
+	{
+		name:    "pgx_pool_acquires_total",
+		help:    "Total amount of successful acquires.",
+		counter: true,
+		value:   func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) },
+	},
+	{
+		name:    "pgx_pool_acquire_duration_seconds_total",
+		help:    "Total duration of successful acquires in seconds.",
+		counter: true,
+		value:   func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() },
+	},
+	{
+		name:    "pgx_pool_empty_acquires_total",
+		help:    "Total amount of acquires which waited for a connection.",
+		counter: true,
+		value:   func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) },
+	},
+	{
+		name:    "pgx_pool_canceled_acquires_total",
+		help:    "Total amount of acquires canceled by a context.",
+		counter: true,
+		value: func(s *pgxpool.Stat) float64 {
+			return float64(s.CanceledAcquireCount())
+		},
+	},
+	{
+		name:    "pgx_pool_new_conns_total",
+		help:    "Total amount of new connections opened.",
+		counter: true,
+		value:   func(s *pgxpool.Stat) float64 { return float64(s.NewConnsCount()) },
+	},
+	{
+		name:    "pgx_pool_max_lifetime_destroys_total",
+		help:    "Total amount of connections closed because of their lifetime.",
+		counter: true,
+		value: func(s *pgxpool.Stat) float64 {
+			return float64(s.MaxLifetimeDestroyCount())
+		},
+	},
+	{
+		name:    "pgx_pool_max_idle_destroys_total",
+		help:    "Total amount of connections closed because of their idle time.",
+		counter: true,
+		value: func(s *pgxpool.Stat) float64 {
+			return float64(s.MaxIdleDestroyCount())
+		},
+	},
+}
+
+// RegisterPoolMetrics exposes the statistics of
+// a [*pgxpool.Pool] in registry, labeled by name.
+func RegisterPoolMetrics(
+	registry *metrics.Registry,
+	name string,
+	pool *pgxpool.Pool,
+) {
+	for _, stat := range poolStats {
+		collect := func() []metrics.Sample {
+			return []metrics.Sample{
+				{LabelValues: []string{name}, Value: stat.value(pool.Stat())},
+			}
+		}
+
+		if stat.counter {
+			registry.CounterFunc(stat.name, stat.help, []string{"pool"}, collect)
+		} else {
+			registry.GaugeFunc(stat.name, stat.help, []string{"pool"}, collect)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package postgres_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/XDoubleU/essentia/pkg/config"
+	"github.com/XDoubleU/essentia/pkg/database/postgres"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterPoolMetrics(t *testing.T) {
+	logger := logging.NewNopLogger()
+	dsn := config.New(logger).EnvStr("DB_DSN", "postgres://postgres@localhost/postgres")
+
+	pool, err := postgres.Connect(logger, dsn, 5, "1m", 5, time.Second, 5*time.Second)
+	require.Nil(t, err)
+	defer pool.Close()
+
+	registry := metrics.NewRegistry()
+	postgres.RegisterPoolMetrics(registry, "main", pool)
+
+	_, err = pool.Exec(context.Background(), "SELECT 1")
+	require.Nil(t, err)
+
+	rr := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	output := rr.Body.String()
+	assert.Contains(t, output, `pgx_pool_max_conns{pool="main"} 5`)
+	assert.Contains(t, output, "# TYPE pgx_pool_acquires_total counter")
+	assert.Contains(t, output, `pgx_pool_idle_conns{pool="main"}`)
+}
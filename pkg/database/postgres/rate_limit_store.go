@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/XDoubleU/essentia/pkg/stores"
+	"github.com/jackc/pgx/v5"
+)
+
+// RateLimitStore is a [stores.RateLimitStore] storing the
+// [stores.TokenBucket]s of clients in a postgres table,
+// so multiple instances of an application share their limits.
+// It can be added to a [threading.JobQueue] to remove stale buckets.
+type RateLimitStore struct {
+	db              DB
+	table           string
+	cleanupInterval time.Duration
+	removeAfter     time.Duration
+}
+
+// NewRateLimitStore creates a new [RateLimitStore] using the provided table,
+// which can be created using [RateLimitStore.CreateTable]. When used as job,
+// buckets which weren't updated for removeAfter are removed every
+// cleanupInterval.
+func NewRateLimitStore(
+	db DB,
+	table string,
+	cleanupInterval time.Duration,
+	removeAfter time.Duration,
+) *RateLimitStore {
+	return &RateLimitStore{
+		db:              db,
+		table:           pgx.Identifier{table}.Sanitize(),
+		cleanupInterval: cleanupInterval,
+		removeAfter:     removeAfter,
+	}
+}
+
+// CreateTable creates the table of a [RateLimitStore] if it doesn't exist.
+func (s *RateLimitStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.Exec(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			key TEXT PRIMARY KEY,
+			tokens DOUBLE PRECISION NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL
+		)`, s.table))
+	return err
+}
+
+// Take takes a token of the bucket of a key.
+// The time of the database is used, so all instances agree on it.
+// This is the time the bucket was locked instead of the start of the
+// transaction, which is earlier than the last update when waiting on it.
+func (s *RateLimitStore) Take(
+	ctx context.Context,
+	key string,
+	limit stores.Limit,
+) (stores.RateLimitResult, error) {
+	var result stores.RateLimitResult
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	//nolint:errcheck //rollback after commit is a no-op
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO %s (key, tokens, updated_at)
+		VALUES ($1, $2, clock_timestamp())
+		ON CONFLICT (key) DO NOTHING`, s.table),
+		key,
+		float64(limit.Burst),
+	)
+	if err != nil {
+		return result, err
+	}
+
+	var bucket stores.TokenBucket
+	var now time.Time
+	err = tx.QueryRow(ctx, fmt.Sprintf(`
+		SELECT tokens, updated_at, clock_timestamp()
+		FROM %s
+		WHERE key = $1
+		FOR UPDATE`, s.table),
+		key,
+	).Scan(&bucket.Tokens, &bucket.Updated, &now)
+	if err != nil {
+		return result, err
+	}
+
+	result = stores.TakeToken(&bucket, limit, now)
+
+	_, err = tx.Exec(ctx, fmt.Sprintf(`
+		UPDATE %s
+		SET tokens = $2, updated_at = $3
+		WHERE key = $1`, s.table),
+		key,
+		bucket.Tokens,
+		bucket.Updated,
+	)
+	if err != nil {
+		return result, err
+	}
+
+	return result, tx.Commit(ctx)
+}
+
+// ID returns the id of the cleanup job of a [RateLimitStore].
+func (s *RateLimitStore) ID() string {
+	return "rate-limit-cleanup-" + s.table
+}
+
+// Run removes the buckets which weren't updated for removeAfter.
+func (s *RateLimitStore) Run(ctx context.Context, logger *slog.Logger) error {
+	tag, err := s.db.Exec(ctx, fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE updated_at < now() - $1::interval`, s.table),
+		s.removeAfter,
+	)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug(
+		"removed stale rate limit buckets",
+		slog.Int64("amount", tag.RowsAffected()),
+	)
+	return nil
+}
+
+// RunEvery returns the interval of the cleanup job of a [RateLimitStore].
+func (s *RateLimitStore) RunEvery() time.Duration {
+	return s.cleanupInterval
+}
@@ -0,0 +1,111 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/XDoubleU/essentia/pkg/config"
+	"github.com/XDoubleU/essentia/pkg/database/postgres"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/stores"
+	"github.com/XDoubleU/essentia/pkg/threading"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ stores.RateLimitStore = &postgres.RateLimitStore{}
+	_ threading.Job         = &postgres.RateLimitStore{}
+)
+
+func TestRateLimitStore(t *testing.T) {
+	logger := logging.NewNopLogger()
+	dsn := config.New(logger).EnvStr("DB_DSN", "postgres://postgres@localhost/postgres")
+
+	pool, err := postgres.Connect(logger, dsn, 5, "1m", 5, time.Second, 5*time.Second)
+	require.Nil(t, err)
+	defer pool.Close()
+
+	ctx := context.Background()
+	store := postgres.NewRateLimitStore(
+		pool,
+		"essentia_rate_limit_test",
+		time.Minute,
+		time.Millisecond,
+	)
+
+	err = store.CreateTable(ctx)
+	require.Nil(t, err)
+
+	limit := stores.Limit{Rate: 1, Burst: 2}
+	for _, allowed := range []bool{true, true, false} {
+		result, takeErr := store.Take(ctx, "client", limit)
+		require.Nil(t, takeErr)
+		assert.Equal(t, allowed, result.Allowed)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	err = store.Run(ctx, logger)
+	require.Nil(t, err)
+
+	result, err := store.Take(ctx, "client", limit)
+	require.Nil(t, err)
+	assert.Equal(t, 1, result.Remaining)
+}
+
+func TestRateLimitStoreContention(t *testing.T) {
+	logger := logging.NewNopLogger()
+	dsn := config.New(logger).EnvStr("DB_DSN", "postgres://postgres@localhost/postgres")
+
+	pool, err := postgres.Connect(logger, dsn, 5, "1m", 5, time.Second, 5*time.Second)
+	require.Nil(t, err)
+	defer pool.Close()
+
+	ctx := context.Background()
+	table := "essentia_rate_limit_contention_test"
+	store := postgres.NewRateLimitStore(pool, table, time.Minute, time.Minute)
+
+	err = store.CreateTable(ctx)
+	require.Nil(t, err)
+
+	limit := stores.Limit{Rate: 1, Burst: 1}
+	_, err = store.Take(ctx, "client", limit)
+	require.Nil(t, err)
+
+	tx, err := pool.Begin(ctx)
+	require.Nil(t, err)
+
+	_, err = tx.Exec(ctx, fmt.Sprintf(
+		"SELECT 1 FROM %s WHERE key = 'client' FOR UPDATE",
+		table,
+	))
+	require.Nil(t, err)
+
+	results := make(chan stores.RateLimitResult, 1)
+	go func() {
+		result, takeErr := store.Take(ctx, "client", limit)
+		assert.Nil(t, takeErr)
+		results <- result
+	}()
+
+	// the waiting transaction starts long before the bucket is updated
+	time.Sleep(1500 * time.Millisecond)
+
+	_, err = tx.Exec(ctx, fmt.Sprintf(
+		"UPDATE %s SET tokens = 0, updated_at = clock_timestamp() "+
+			"WHERE key = 'client'",
+		table,
+	))
+	require.Nil(t, err)
+	require.Nil(t, tx.Commit(ctx))
+
+	assert.False(t, (<-results).Allowed)
+
+	// the time waited on the lock isn't refilled again
+	result, err := store.Take(ctx, "client", limit)
+	require.Nil(t, err)
+	assert.False(t, result.Allowed)
+}
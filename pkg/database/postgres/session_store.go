@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/XDoubleU/essentia/pkg/stores"
+	"github.com/jackc/pgx/v5"
+)
+
+// SessionStore is a [stores.SessionStore] storing sessions in a postgres table.
+// It can be added to a [threading.JobQueue] to remove expired sessions.
+type SessionStore struct {
+	db              DB
+	table           string
+	cleanupInterval time.Duration
+}
+
+// NewSessionStore creates a new [SessionStore] using the provided table,
+// which can be created using [SessionStore.CreateTable]. When used as job,
+// expired sessions are removed every cleanupInterval.
+func NewSessionStore(
+	db DB,
+	table string,
+	cleanupInterval time.Duration,
+) *SessionStore {
+	return &SessionStore{
+		db:              db,
+		table:           pgx.Identifier{table}.Sanitize(),
+		cleanupInterval: cleanupInterval,
+	}
+}
+
+// CreateTable creates the table of a [SessionStore] if it doesn't exist.
+func (s *SessionStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.Exec(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			data JSONB NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			last_seen_at TIMESTAMPTZ NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL
+		)`, s.table))
+	return err
+}
+
+// Load loads a session, returning nil when it doesn't exist or has expired.
+func (s *SessionStore) Load(
+	ctx context.Context,
+	token string,
+) (*stores.SessionRecord, error) {
+	//nolint:exhaustruct //other fields are optional
+	record := stores.SessionRecord{ID: token}
+
+	var data []byte
+	err := s.db.QueryRow(ctx, fmt.Sprintf(`
+		SELECT data, created_at, last_seen_at
+		FROM %s
+		WHERE id = $1 AND expires_at > now()`, s.table),
+		token,
+	).Scan(&data, &record.CreatedAt, &record.LastSeenAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		//nolint:nilnil //a missing session isn't an error
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &record.Values)
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// Save saves a session, the ID of the session is used as token.
+func (s *SessionStore) Save(
+	ctx context.Context,
+	record stores.SessionRecord,
+	expiresAt time.Time,
+) (string, error) {
+	data, err := json.Marshal(record.Values)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.db.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id, data, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE
+		SET data = $2, last_seen_at = $4, expires_at = $5`, s.table),
+		record.ID,
+		data,
+		record.CreatedAt,
+		record.LastSeenAt,
+		expiresAt,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return record.ID, nil
+}
+
+// Delete deletes a session.
+func (s *SessionStore) Delete(ctx context.Context, token string) error {
+	_, err := s.db.Exec(
+		ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE id = $1", s.table),
+		token,
+	)
+	return err
+}
+
+// ID returns the id of the cleanup job of a [SessionStore].
+func (s *SessionStore) ID() string {
+	return "session-cleanup-" + s.table
+}
+
+// Run removes the expired sessions.
+func (s *SessionStore) Run(ctx context.Context, logger *slog.Logger) error {
+	tag, err := s.db.Exec(
+		ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE expires_at <= now()", s.table),
+	)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug(
+		"removed expired sessions",
+		slog.Int64("amount", tag.RowsAffected()),
+	)
+	return nil
+}
+
+// RunEvery returns the interval of the cleanup job of a [SessionStore].
+func (s *SessionStore) RunEvery() time.Duration {
+	return s.cleanupInterval
+}
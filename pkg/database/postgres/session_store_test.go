@@ -0,0 +1,66 @@
+package postgres_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/XDoubleU/essentia/pkg/config"
+	"github.com/XDoubleU/essentia/pkg/database/postgres"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/stores"
+	"github.com/XDoubleU/essentia/pkg/threading"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ stores.SessionStore = &postgres.SessionStore{}
+	_ threading.Job       = &postgres.SessionStore{}
+)
+
+func TestSessionStore(t *testing.T) {
+	logger := logging.NewNopLogger()
+	dsn := config.New(logger).EnvStr("DB_DSN", "postgres://postgres@localhost/postgres")
+
+	pool, err := postgres.Connect(logger, dsn, 5, "1m", 5, time.Second, 5*time.Second)
+	require.Nil(t, err)
+	defer pool.Close()
+
+	ctx := context.Background()
+	store := postgres.NewSessionStore(pool, "essentia_session_test", time.Minute)
+
+	err = store.CreateTable(ctx)
+	require.Nil(t, err)
+
+	now := time.Now()
+	record := stores.SessionRecord{
+		ID:         "id",
+		Values:     map[string]json.RawMessage{"key": json.RawMessage(`"value"`)},
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	token, err := store.Save(ctx, record, now.Add(time.Hour))
+	require.Nil(t, err)
+	assert.Equal(t, "id", token)
+
+	loaded, err := store.Load(ctx, token)
+	require.Nil(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, record.Values, loaded.Values)
+
+	_, err = store.Save(ctx, record, now.Add(-time.Hour))
+	require.Nil(t, err)
+
+	loaded, err = store.Load(ctx, token)
+	require.Nil(t, err)
+	assert.Nil(t, loaded)
+
+	err = store.Run(ctx, logger)
+	require.Nil(t, err)
+
+	err = store.Delete(ctx, token)
+	require.Nil(t, err)
+}
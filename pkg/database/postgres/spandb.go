@@ -9,7 +9,7 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
-// SpanDB is used to wrap database actions in [sentry.Span]s.
+// SpanDB is used to wrap database actions in [tracing.Span]s.
 type SpanDB struct {
 	DB     DB
 	dbName string
@@ -23,7 +23,7 @@ func NewSpanDB(db DB) *SpanDB {
 	}
 }
 
-// Exec is used to wrap Exec in a [sentry.Span].
+// Exec is used to wrap Exec in a [tracing.Span].
 func (db *SpanDB) Exec(
 	ctx context.Context,
 	sql string,
@@ -32,7 +32,7 @@ func (db *SpanDB) Exec(
 	return database.WrapWithSpan(ctx, db.dbName, db.DB.Exec, sql, arguments...)
 }
 
-// Query is used to wrap Query in a [sentry.Span].
+// Query is used to wrap Query in a [tracing.Span].
 func (db *SpanDB) Query(
 	ctx context.Context,
 	sql string,
@@ -41,7 +41,7 @@ func (db *SpanDB) Query(
 	return database.WrapWithSpan(ctx, db.dbName, db.DB.Query, sql, optionsAndArgs...)
 }
 
-// QueryRow is used to wrap QueryRow in a [sentry.Span].
+// QueryRow is used to wrap QueryRow in a [tracing.Span].
 func (db *SpanDB) QueryRow(
 	ctx context.Context,
 	sql string,
@@ -55,7 +55,7 @@ func (db *SpanDB) QueryRow(
 		optionsAndArgs...)
 }
 
-// SendBatch is used to wrap SendBatch in a [sentry.Span].
+// SendBatch is used to wrap SendBatch in a [tracing.Span].
 func (db *SpanDB) SendBatch(
 	ctx context.Context,
 	b *pgx.Batch,
@@ -65,19 +65,19 @@ func (db *SpanDB) SendBatch(
 		sql += fmt.Sprintf("query %d: %s\n", i, query.SQL)
 	}
 
-	span := database.StartSpan(ctx, db.dbName, sql)
-	defer span.Finish()
+	span := database.StartQuerySpan(ctx, db.dbName, sql)
+	defer span.End()
 
 	return db.DB.SendBatch(ctx, b)
 }
 
-// Begin doesn't wrap Begin in a [sentry.Span] as
+// Begin doesn't wrap Begin in a [tracing.Span] as
 // this makes little sense for starting a transaction.
 func (db *SpanDB) Begin(ctx context.Context) (pgx.Tx, error) {
 	return db.DB.Begin(ctx)
 }
 
-// BeginTx doesn't wrap BeginTx in a [sentry.Span] as
+// BeginTx doesn't wrap BeginTx in a [tracing.Span] as
 // this makes little sense for starting a transaction.
 func (db *SpanDB) BeginTx(
 	ctx context.Context,
@@ -86,7 +86,7 @@ func (db *SpanDB) BeginTx(
 	return db.DB.BeginTx(ctx, txOptions)
 }
 
-// Ping doesn't wrap Ping in a [sentry.Span] as
+// Ping doesn't wrap Ping in a [tracing.Span] as
 // this makes little sense for pinging the db.
 func (db *SpanDB) Ping(ctx context.Context) error {
 	return db.DB.Ping(ctx)
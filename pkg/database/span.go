@@ -3,10 +3,14 @@ package database
 import (
 	"context"
 
+	"github.com/XDoubleU/essentia/pkg/tracing"
 	"github.com/getsentry/sentry-go"
 )
 
 // StartSpan is used to start a [sentry.Span].
+//
+// Deprecated: StartSpan only supports Sentry,
+// use [StartQuerySpan] to use the configured [tracing.Tracer].
 func StartSpan(ctx context.Context, dbName string, sql string) *sentry.Span {
 	transaction := sentry.TransactionFromContext(ctx)
 
@@ -24,29 +28,48 @@ func StartSpan(ctx context.Context, dbName string, sql string) *sentry.Span {
 	return span
 }
 
+// StartQuerySpan is used to start a [tracing.Span] of a database query.
+func StartQuerySpan(ctx context.Context, dbName string, sql string) tracing.Span {
+	_, span := tracing.Start(
+		ctx,
+		sql,
+		tracing.WithKind(tracing.SpanKindClient),
+		tracing.WithOperation("db.query"),
+		tracing.WithAttribute("db.system", dbName),
+		tracing.WithAttribute("db.statement", sql),
+	)
+
+	return span
+}
+
 // WrapWithSpan is used to wrap a
-// database action in a [sentry.Span].
+// database action in a [tracing.Span].
 func WrapWithSpan[T any](
 	ctx context.Context,
 	dbName string,
 	queryFunc func(ctx context.Context, sql string, args ...any) (T, error),
 	sql string, args ...any) (T, error) {
-	span := StartSpan(ctx, dbName, sql)
-	defer span.Finish()
+	span := StartQuerySpan(ctx, dbName, sql)
+	defer span.End()
 
-	return queryFunc(ctx, sql, args...)
+	result, err := queryFunc(ctx, sql, args...)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return result, err
 }
 
 // WrapWithSpanNoError is used to wrap a
-// database action in a [sentry.Span].
+// database action in a [tracing.Span].
 // The executed database action shouldn't return an error.
 func WrapWithSpanNoError[T any](
 	ctx context.Context,
 	dbName string,
 	queryFunc func(ctx context.Context, sql string, args ...any) T,
 	sql string, args ...any) T {
-	span := StartSpan(ctx, dbName, sql)
-	defer span.Finish()
+	span := StartQuerySpan(ctx, dbName, sql)
+	defer span.End()
 
 	return queryFunc(ctx, sql, args...)
 }
@@ -0,0 +1,63 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/XDoubleU/essentia/pkg/database"
+	"github.com/XDoubleU/essentia/pkg/tracing"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWrapWithSpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracing.SetTracer(tracing.NewOTelTracer(provider))
+	defer tracing.SetTracer(tracing.NewSentryTracer())
+
+	ctx, parent := tracing.Start(context.Background(), "request")
+
+	query := func(_ context.Context, _ string, _ ...any) (int, error) {
+		return 0, errors.New("failed")
+	}
+
+	_, err := database.WrapWithSpan(ctx, "postgresql", query, "SELECT 1")
+	assert.EqualError(t, err, "failed")
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "SELECT 1", spans[0].Name)
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind)
+	assert.Contains(
+		t,
+		spans[0].Attributes,
+		attribute.String("db.system", "postgresql"),
+	)
+	assert.Equal(
+		t,
+		parent.SpanContext().SpanID.String(),
+		spans[0].Parent.SpanID().String(),
+	)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+}
+
+func TestStartSpan(t *testing.T) {
+	ctx := sentry.StartTransaction(context.Background(), "request").Context()
+
+	//nolint:staticcheck //the deprecated function should keep working
+	span := database.StartSpan(ctx, "postgresql", "SELECT 1")
+	defer span.Finish()
+
+	assert.Equal(t, "db.query", span.Op)
+	assert.Equal(t, "SELECT 1", span.Description)
+	assert.Equal(t, "postgresql", span.Data["db.system"])
+}
@@ -30,6 +30,12 @@ type UnauthorizedError struct {
 	err error
 }
 
+// FailedValidationError is used when the input
+// of a request isn't valid, containing the invalid fields.
+type FailedValidationError struct {
+	Errors map[string]string
+}
+
 // NewNotFoundError creates a new [NotFoundError].
 func NewNotFoundError(
 	resourceName string,
@@ -105,3 +111,14 @@ func NewUnauthorizedError(err error) UnauthorizedError {
 func (err UnauthorizedError) Error() string {
 	return err.err.Error()
 }
+
+// NewFailedValidationError creates a new [FailedValidationError].
+func NewFailedValidationError(errors map[string]string) FailedValidationError {
+	return FailedValidationError{
+		Errors: errors,
+	}
+}
+
+func (err FailedValidationError) Error() string {
+	return "failed validation"
+}
@@ -1,19 +1,66 @@
 package errors
 
-import "net/http"
+import (
+	stderrors "errors"
+	"net/http"
+)
 
 // ErrorDto is used to return the error back to the client.
 type ErrorDto struct {
 	Status  int    `json:"status"`
 	Error   string `json:"error"`
 	Message any    `json:"message"`
+	// RequestID is the ID of the request which caused the error,
+	// which can be used to find it in the logs.
+	RequestID string `json:"requestId,omitempty"`
 } //	@name	ErrorDto
 
 // NewErrorDto creates a new [ErrorDto].
 func NewErrorDto(status int, message any) ErrorDto {
 	return ErrorDto{
-		Status:  status,
-		Error:   http.StatusText(status),
-		Message: message,
+		Status:    status,
+		Error:     http.StatusText(status),
+		Message:   message,
+		RequestID: "",
+	}
+}
+
+// ToErrorDto translates the errors of this package to an [ErrorDto] with
+// the matching status. For other errors false is returned, which should be
+// handled as server errors without exposing their message.
+func ToErrorDto(err error) (ErrorDto, bool) {
+	//nolint:exhaustruct //fields are not needed here
+	validationError := FailedValidationError{}
+	//nolint:exhaustruct //fields are not needed here
+	unauthorizedError := UnauthorizedError{}
+	//nolint:exhaustruct //fields are not needed here
+	badRequestError := BadRequestError{}
+	//nolint:exhaustruct //fields are not needed here
+	notFoundError := NotFoundError{}
+	//nolint:exhaustruct //fields are not needed here
+	conflictError := ConflictError{}
+
+	switch {
+	case stderrors.As(err, &validationError):
+		return NewErrorDto(
+			http.StatusUnprocessableEntity,
+			validationError.Errors,
+		), true
+	case stderrors.As(err, &unauthorizedError):
+		return NewErrorDto(http.StatusUnauthorized, unauthorizedError.Error()), true
+	case stderrors.As(err, &badRequestError):
+		return NewErrorDto(http.StatusBadRequest, badRequestError.Error()), true
+	case stderrors.As(err, &notFoundError):
+		return NewErrorDto(
+			http.StatusNotFound,
+			map[string]string{notFoundError.JSONField: notFoundError.Error()},
+		), true
+	case stderrors.As(err, &conflictError):
+		return NewErrorDto(
+			http.StatusConflict,
+			map[string]string{conflictError.JSONField: conflictError.Error()},
+		), true
+	default:
+		return NewErrorDto(http.StatusInternalServerError, nil), false
 	}
 }
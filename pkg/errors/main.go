@@ -7,4 +7,6 @@ const (
 	MessageInternalServerError = "the server encountered a problem and could not process your request"
 	MessageTooManyRequests     = "rate limit exceeded"
 	MessageForbidden           = "user has no access to this resource"
+	MessageServiceUnavailable  = "the server is temporarily unable to handle your request"
+	MessageTimeout             = "the server took too long to process your request"
 )
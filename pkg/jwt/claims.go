@@ -0,0 +1,91 @@
+package jwt
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ClaimsType is implemented by [Claims] and custom
+// claims embedding [Claims], so these can be verified.
+type ClaimsType interface {
+	Registered() Claims
+}
+
+// Claims contains the registered claims of a JSON Web Token,
+// together with the commonly used scope and roles claims.
+type Claims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  Audience     `json:"aud,omitempty"`
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+	Scope     string       `json:"scope,omitempty"`
+	Roles     []string     `json:"roles,omitempty"`
+}
+
+// Registered returns the registered [Claims].
+func (claims Claims) Registered() Claims {
+	return claims
+}
+
+// HasScope checks if the space-separated scope claim contains scope.
+func (claims Claims) HasScope(scope string) bool {
+	return slices.Contains(strings.Fields(claims.Scope), scope)
+}
+
+// HasRole checks if the roles claim contains role.
+func (claims Claims) HasRole(role string) bool {
+	return slices.Contains(claims.Roles, role)
+}
+
+// Audience is the aud claim, which can be a string or an array of strings.
+type Audience []string
+
+// UnmarshalJSON parses a string or an array of strings.
+func (audience *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*audience = Audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+
+	*audience = multiple
+	return nil
+}
+
+// NumericDate is a time represented as seconds since the epoch.
+type NumericDate struct {
+	time.Time
+}
+
+// NewNumericDate creates a new [NumericDate].
+func NewNumericDate(t time.Time) *NumericDate {
+	return &NumericDate{Time: t.Truncate(time.Second)}
+}
+
+// MarshalJSON formats a [NumericDate] as seconds since the epoch.
+func (date NumericDate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(date.Unix())
+}
+
+// UnmarshalJSON parses seconds since the epoch, which can have a fraction.
+func (date *NumericDate) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return err
+	}
+
+	whole, fraction := math.Modf(seconds)
+	date.Time = time.Unix(int64(whole), int64(fraction*float64(time.Second)))
+	return nil
+}
@@ -0,0 +1,237 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// Algorithm is a supported signing algorithm.
+type Algorithm string
+
+// Supported signing algorithms.
+const (
+	HS256 Algorithm = "HS256"
+	RS256 Algorithm = "RS256"
+	EdDSA Algorithm = "EdDSA"
+)
+
+// MinHMACSecretLength is the minimum length in bytes of HS256 secrets,
+// which is the size of the SHA-256 hash as recommended by RFC 7518.
+const MinHMACSecretLength = 32
+
+// ErrWeakSecret is returned when an HS256 secret
+// is shorter than [MinHMACSecretLength].
+var ErrWeakSecret = fmt.Errorf(
+	"HMAC secret has to be at least %d bytes",
+	MinHMACSecretLength,
+)
+
+// Key is a key used to verify the signatures of tokens.
+type Key struct {
+	ID        string
+	Algorithm Algorithm
+	key       any
+}
+
+// KeySet is a set of [Key]s, tokens are verified using the key
+// matching their kid header or otherwise any key of their algorithm.
+type KeySet struct {
+	keys []Key
+}
+
+// NewKeySet creates a new [KeySet].
+func NewKeySet(keys ...Key) KeySet {
+	return KeySet{keys: keys}
+}
+
+// NewHMACKey creates a new HS256 [Key] using a shared secret,
+// which has to be at least [MinHMACSecretLength] bytes.
+func NewHMACKey(id string, secret []byte) (Key, error) {
+	if len(secret) < MinHMACSecretLength {
+		return Key{}, ErrWeakSecret
+	}
+
+	return Key{ID: id, Algorithm: HS256, key: secret}, nil
+}
+
+// NewRSAKey creates a new RS256 [Key] using a public key.
+func NewRSAKey(id string, publicKey *rsa.PublicKey) Key {
+	return Key{ID: id, Algorithm: RS256, key: publicKey}
+}
+
+// NewEd25519Key creates a new EdDSA [Key] using a public key.
+func NewEd25519Key(id string, publicKey ed25519.PublicKey) Key {
+	return Key{ID: id, Algorithm: EdDSA, key: publicKey}
+}
+
+// LoadKeyFile loads a [Key] from a file. For HS256 the file contains the
+// secret, for RS256 and EdDSA a PEM encoded public key or certificate.
+func LoadKeyFile(id string, algorithm Algorithm, path string) (Key, error) {
+	//nolint:gosec //path is provided by the application
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+
+	if algorithm == HS256 {
+		return NewHMACKey(id, bytes.TrimSpace(data))
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("'%s' doesn't contain a PEM block", path)
+	}
+
+	publicKey, err := parsePublicKey(block)
+	if err != nil {
+		return Key{}, err
+	}
+
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		if algorithm == RS256 {
+			return NewRSAKey(id, publicKey), nil
+		}
+	case ed25519.PublicKey:
+		if algorithm == EdDSA {
+			return NewEd25519Key(id, publicKey), nil
+		}
+	}
+
+	return Key{}, fmt.Errorf("'%s' doesn't contain a %s key", path, algorithm)
+}
+
+func parsePublicKey(block *pem.Block) (any, error) {
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		return certificate.PublicKey, nil
+	default:
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+}
+
+// LoadJWKSFile loads a [KeySet] from a file containing a JWKS.
+func LoadJWKSFile(path string) (KeySet, error) {
+	//nolint:gosec //path is provided by the application
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return KeySet{}, err
+	}
+
+	return ParseJWKS(data)
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	KeyType   string `json:"kty"`
+	ID        string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv"`
+	K         string `json:"k"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	X         string `json:"x"`
+}
+
+// ParseJWKS parses a JWKS into a [KeySet]. Keys of
+// unsupported types or used for encryption are skipped.
+func ParseJWKS(data []byte) (KeySet, error) {
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return KeySet{}, err
+	}
+
+	keys := []Key{}
+	for _, jwk := range set.Keys {
+		if jwk.Use == "enc" {
+			continue
+		}
+
+		key, err := jwk.toKey()
+		if err != nil {
+			return KeySet{}, fmt.Errorf("key '%s': %w", jwk.ID, err)
+		}
+
+		if key != nil {
+			keys = append(keys, *key)
+		}
+	}
+
+	return NewKeySet(keys...), nil
+}
+
+func (jwk jwk) toKey() (*Key, error) {
+	var key Key
+
+	switch {
+	case jwk.KeyType == "oct" && isAlgorithm(jwk.Algorithm, HS256):
+		secret, err := decodeSegment(jwk.K)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err = NewHMACKey(jwk.ID, secret)
+		if err != nil {
+			return nil, err
+		}
+	case jwk.KeyType == "RSA" && isAlgorithm(jwk.Algorithm, RS256):
+		n, err := decodeSegment(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeSegment(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		key = NewRSAKey(jwk.ID, &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		})
+	case jwk.KeyType == "OKP" && jwk.Curve == "Ed25519" &&
+		isAlgorithm(jwk.Algorithm, EdDSA):
+		x, err := decodeSegment(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+
+		key = NewEd25519Key(jwk.ID, x)
+	default:
+		return nil, nil
+	}
+
+	return &key, nil
+}
+
+func isAlgorithm(value string, algorithm Algorithm) bool {
+	return value == "" || Algorithm(value) == algorithm
+}
+
+func decodeSegment(segment string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(segment)
+}
@@ -0,0 +1,130 @@
+package jwt_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/XDoubleU/essentia/pkg/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.Nil(t, os.WriteFile(path, data, 0o600))
+
+	return path
+}
+
+func writePublicKey(t *testing.T, publicKey any) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.Nil(t, err)
+
+	//nolint:exhaustruct //other fields are optional
+	return writeFile(t, "key.pem", pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: der,
+	}))
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+
+	hmacKey, err := jwt.LoadKeyFile(
+		"hmac",
+		jwt.HS256,
+		writeFile(t, "secret", append(secret, '\n')),
+	)
+	require.Nil(t, err)
+
+	_, err = jwt.LoadKeyFile("hmac", jwt.HS256, writeFile(t, "empty", nil))
+	assert.ErrorIs(t, err, jwt.ErrWeakSecret)
+
+	rsaPath := writePublicKey(t, &rsaKey.PublicKey)
+	rsaPublicKey, err := jwt.LoadKeyFile("rsa", jwt.RS256, rsaPath)
+	require.Nil(t, err)
+
+	edKey, err := jwt.LoadKeyFile("ed", jwt.EdDSA, writePublicKey(t, edPublic))
+	require.Nil(t, err)
+
+	_, err = jwt.LoadKeyFile("rsa", jwt.EdDSA, rsaPath)
+	assert.NotNil(t, err)
+
+	_, err = jwt.LoadKeyFile("rsa", jwt.RS256, writeFile(t, "key", []byte("key")))
+	assert.NotNil(t, err)
+
+	verifier := jwt.NewVerifier(
+		jwt.NewKeySet(hmacKey, rsaPublicKey, edKey),
+		"",
+		"",
+		0,
+	)
+
+	for _, token := range []string{
+		sign(t, jwt.HS256, "", secret, validClaims()),
+		sign(t, jwt.RS256, "", rsaKey, validClaims()),
+		sign(t, jwt.EdDSA, "", edPrivate, validClaims()),
+	} {
+		_, err = jwt.Verify[jwt.Claims](verifier, token)
+		assert.Nil(t, err)
+	}
+}
+
+func TestLoadJWKSFile(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+
+	encode := base64.RawURLEncoding.EncodeToString
+	jwks := fmt.Sprintf(`{"keys": [
+		{"kty": "oct", "kid": "hmac", "k": "%s"},
+		{"kty": "RSA", "kid": "rsa", "alg": "RS256", "n": "%s", "e": "%s"},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "%s"},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "", "e": ""},
+		{"kty": "EC", "kid": "ec", "crv": "P-256"}
+	]}`,
+		encode(secret),
+		encode(rsaKey.N.Bytes()),
+		encode(big.NewInt(int64(rsaKey.E)).Bytes()),
+		encode(edPublic),
+	)
+
+	keySet, err := jwt.LoadJWKSFile(writeFile(t, "jwks.json", []byte(jwks)))
+	require.Nil(t, err)
+
+	verifier := jwt.NewVerifier(keySet, "", "", 0)
+
+	for _, token := range []string{
+		sign(t, jwt.HS256, "hmac", secret, validClaims()),
+		sign(t, jwt.RS256, "rsa", rsaKey, validClaims()),
+		sign(t, jwt.EdDSA, "ed", edPrivate, validClaims()),
+	} {
+		_, err = jwt.Verify[jwt.Claims](verifier, token)
+		assert.Nil(t, err)
+	}
+
+	_, err = jwt.ParseJWKS([]byte(`{"keys": [{"kty": "oct", "k": "!"}]}`))
+	assert.NotNil(t, err)
+
+	_, err = jwt.ParseJWKS([]byte(`{"keys": [{"kty": "oct", "k": "c2VjcmV0"}]}`))
+	assert.ErrorIs(t, err, jwt.ErrWeakSecret)
+}
@@ -0,0 +1,3 @@
+// Package jwt provides verification of JSON Web Tokens signed using
+// HS256, RS256 or EdDSA, with keys loaded from files or a static JWKS.
+package jwt
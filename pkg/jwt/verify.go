@@ -0,0 +1,194 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Errors returned when verifying a token.
+var (
+	ErrMalformedToken   = errors.New("token is malformed")
+	ErrInvalidSignature = errors.New("token signature is invalid")
+	ErrExpired          = errors.New("token is expired")
+	ErrNoExpiration     = errors.New("token has no expiration")
+	ErrNotValidYet      = errors.New("token is not valid yet")
+	ErrInvalidIssuer    = errors.New("token issuer is invalid")
+	ErrInvalidAudience  = errors.New("token audience is invalid")
+)
+
+// Verifier verifies tokens using a [KeySet]. When set, the issuer and
+// audience of tokens have to match. Tokens have to expire, unless
+// [WithoutExpiration] is used. Times are checked allowing clockSkew.
+type Verifier struct {
+	keys              KeySet
+	issuer            string
+	audience          string
+	clockSkew         time.Duration
+	requireExpiration bool
+	now               func() time.Time
+}
+
+// VerifierOption configures a [Verifier].
+type VerifierOption func(verifier *Verifier)
+
+// WithoutExpiration allows tokens without exp claim,
+// these are valid until the key used to sign them is replaced.
+func WithoutExpiration() VerifierOption {
+	return func(verifier *Verifier) {
+		verifier.requireExpiration = false
+	}
+}
+
+type header struct {
+	Algorithm Algorithm `json:"alg"`
+	KeyID     string    `json:"kid"`
+}
+
+// NewVerifier creates a new [Verifier].
+func NewVerifier(
+	keys KeySet,
+	issuer string,
+	audience string,
+	clockSkew time.Duration,
+	options ...VerifierOption,
+) *Verifier {
+	verifier := &Verifier{
+		keys:              keys,
+		issuer:            issuer,
+		audience:          audience,
+		clockSkew:         clockSkew,
+		requireExpiration: true,
+		now:               time.Now,
+	}
+
+	for _, option := range options {
+		option(verifier)
+	}
+
+	return verifier
+}
+
+// SetNow sets the function returning the current time, used for testing.
+func (v *Verifier) SetNow(now func() time.Time) {
+	v.now = now
+}
+
+// Verify verifies the signature and claims of a token
+// and returns its claims decoded into T.
+func Verify[T ClaimsType](v *Verifier, token string) (T, error) {
+	var claims T
+
+	payload, err := v.verifySignature(token)
+	if err != nil {
+		return claims, err
+	}
+
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return claims, ErrMalformedToken
+	}
+
+	return claims, v.verifyClaims(claims.Registered())
+}
+
+func (v *Verifier) verifySignature(token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	//nolint:mnd //header, payload and signature
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	headerData, err := decodeSegment(parts[0])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	var header header
+	if err = json.Unmarshal(headerData, &header); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	for _, key := range v.keys.keys {
+		if key.Algorithm != header.Algorithm {
+			continue
+		}
+
+		if header.KeyID != "" && key.ID != "" && key.ID != header.KeyID {
+			continue
+		}
+
+		if key.verify(signed, signature) {
+			return payload, nil
+		}
+	}
+
+	return nil, ErrInvalidSignature
+}
+
+func (key Key) verify(signed []byte, signature []byte) bool {
+	switch key.Algorithm {
+	case HS256:
+		secret, _ := key.key.([]byte)
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), signature)
+	case RS256:
+		publicKey, _ := key.key.(*rsa.PublicKey)
+		digest := sha256.Sum256(signed)
+		return rsa.VerifyPKCS1v15(
+			publicKey,
+			crypto.SHA256,
+			digest[:],
+			signature,
+		) == nil
+	case EdDSA:
+		publicKey, _ := key.key.(ed25519.PublicKey)
+		return ed25519.Verify(publicKey, signed, signature)
+	default:
+		return false
+	}
+}
+
+func (v *Verifier) verifyClaims(claims Claims) error {
+	now := v.now()
+
+	if claims.ExpiresAt == nil && v.requireExpiration {
+		return ErrNoExpiration
+	}
+
+	if claims.ExpiresAt != nil && !now.Before(claims.ExpiresAt.Add(v.clockSkew)) {
+		return ErrExpired
+	}
+
+	if claims.NotBefore != nil && now.Before(claims.NotBefore.Add(-v.clockSkew)) {
+		return ErrNotValidYet
+	}
+
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return ErrInvalidIssuer
+	}
+
+	if v.audience != "" && !slices.Contains(claims.Audience, v.audience) {
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
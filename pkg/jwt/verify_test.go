@@ -0,0 +1,275 @@
+package jwt_test
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/XDoubleU/essentia/pkg/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("a-secret-of-at-least-thirty-two-bytes")
+
+type customClaims struct {
+	jwt.Claims
+	Name string `json:"name"`
+}
+
+func encodeSegment(t *testing.T, value any) string {
+	t.Helper()
+
+	data, err := json.Marshal(value)
+	require.Nil(t, err)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func sign(
+	t *testing.T,
+	algorithm jwt.Algorithm,
+	kid string,
+	key any,
+	claims any,
+) string {
+	t.Helper()
+
+	header := map[string]string{"alg": string(algorithm), "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+
+	signed := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+
+	var signature []byte
+	switch algorithm {
+	case jwt.HS256:
+		secret, _ := key.([]byte)
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case jwt.RS256:
+		privateKey, _ := key.(*rsa.PrivateKey)
+		digest := sha256.Sum256([]byte(signed))
+
+		var err error
+		signature, err = rsa.SignPKCS1v15(
+			rand.Reader,
+			privateKey,
+			crypto.SHA256,
+			digest[:],
+		)
+		require.Nil(t, err)
+	case jwt.EdDSA:
+		privateKey, _ := key.(ed25519.PrivateKey)
+		signature = ed25519.Sign(privateKey, []byte(signed))
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newHMACKey(t *testing.T, id string) jwt.Key {
+	t.Helper()
+
+	key, err := jwt.NewHMACKey(id, secret)
+	require.Nil(t, err)
+
+	return key
+}
+
+func validClaims() jwt.Claims {
+	//nolint:exhaustruct //other fields are optional
+	return jwt.Claims{
+		Issuer:    "issuer",
+		Subject:   "user",
+		Audience:  jwt.Audience{"api"},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		NotBefore: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+	}
+}
+
+func TestVerifyAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+
+	verifier := jwt.NewVerifier(
+		jwt.NewKeySet(
+			newHMACKey(t, "hmac"),
+			jwt.NewRSAKey("rsa", &rsaKey.PublicKey),
+			jwt.NewEd25519Key("ed", edPublic),
+		),
+		"issuer",
+		"api",
+		0,
+	)
+
+	tokens := []string{
+		sign(t, jwt.HS256, "hmac", secret, validClaims()),
+		sign(t, jwt.RS256, "rsa", rsaKey, validClaims()),
+		sign(t, jwt.EdDSA, "", edPrivate, validClaims()),
+	}
+
+	for _, token := range tokens {
+		claims, verifyErr := jwt.Verify[jwt.Claims](verifier, token)
+		require.Nil(t, verifyErr)
+		assert.Equal(t, "user", claims.Subject)
+	}
+
+	_, err = jwt.Verify[jwt.Claims](
+		verifier,
+		sign(t, jwt.HS256, "hmac", []byte("wrong"), validClaims()),
+	)
+	assert.ErrorIs(t, err, jwt.ErrInvalidSignature)
+
+	_, err = jwt.Verify[jwt.Claims](
+		verifier,
+		sign(t, jwt.HS256, "rsa", secret, validClaims()),
+	)
+	assert.ErrorIs(t, err, jwt.ErrInvalidSignature)
+
+	_, err = jwt.Verify[jwt.Claims](
+		verifier,
+		sign(t, "none", "", nil, validClaims()),
+	)
+	assert.ErrorIs(t, err, jwt.ErrInvalidSignature)
+
+	_, err = jwt.Verify[jwt.Claims](verifier, "invalid")
+	assert.ErrorIs(t, err, jwt.ErrMalformedToken)
+}
+
+func TestVerifyClaims(t *testing.T) {
+	now := time.Now()
+
+	verifier := jwt.NewVerifier(
+		jwt.NewKeySet(newHMACKey(t, "")),
+		"issuer",
+		"api",
+		time.Minute,
+	)
+	verifier.SetNow(func() time.Time { return now })
+
+	tests := []struct {
+		name   string
+		modify func(claims *jwt.Claims)
+		err    error
+	}{
+		{
+			name:   "valid",
+			modify: func(_ *jwt.Claims) {},
+			err:    nil,
+		},
+		{
+			name: "expired within skew",
+			modify: func(claims *jwt.Claims) {
+				claims.ExpiresAt = jwt.NewNumericDate(now.Add(-30 * time.Second))
+			},
+			err: nil,
+		},
+		{
+			name: "expired",
+			modify: func(claims *jwt.Claims) {
+				claims.ExpiresAt = jwt.NewNumericDate(now.Add(-2 * time.Minute))
+			},
+			err: jwt.ErrExpired,
+		},
+		{
+			name: "no expiration",
+			modify: func(claims *jwt.Claims) {
+				claims.ExpiresAt = nil
+			},
+			err: jwt.ErrNoExpiration,
+		},
+		{
+			name: "not valid yet",
+			modify: func(claims *jwt.Claims) {
+				claims.NotBefore = jwt.NewNumericDate(now.Add(2 * time.Minute))
+			},
+			err: jwt.ErrNotValidYet,
+		},
+		{
+			name: "invalid issuer",
+			modify: func(claims *jwt.Claims) {
+				claims.Issuer = "other"
+			},
+			err: jwt.ErrInvalidIssuer,
+		},
+		{
+			name: "invalid audience",
+			modify: func(claims *jwt.Claims) {
+				claims.Audience = jwt.Audience{"other", "another"}
+			},
+			err: jwt.ErrInvalidAudience,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.modify(&claims)
+
+			_, err := jwt.Verify[jwt.Claims](
+				verifier,
+				sign(t, jwt.HS256, "", secret, claims),
+			)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestVerifyCustomClaims(t *testing.T) {
+	verifier := jwt.NewVerifier(
+		jwt.NewKeySet(newHMACKey(t, "")),
+		"",
+		"",
+		0,
+	)
+
+	claims := validClaims()
+	claims.Scope = "read write"
+	claims.Roles = []string{"admin"}
+
+	token := sign(t, jwt.HS256, "", secret, customClaims{
+		Claims: claims,
+		Name:   "name",
+	})
+
+	result, err := jwt.Verify[customClaims](verifier, token)
+	require.Nil(t, err)
+
+	assert.Equal(t, "name", result.Name)
+	assert.True(t, result.HasScope("write"))
+	assert.False(t, result.HasScope("delete"))
+	assert.True(t, result.HasRole("admin"))
+}
+
+func TestVerifyWithoutExpiration(t *testing.T) {
+	verifier := jwt.NewVerifier(
+		jwt.NewKeySet(newHMACKey(t, "")),
+		"",
+		"",
+		0,
+		jwt.WithoutExpiration(),
+	)
+
+	claims := validClaims()
+	claims.ExpiresAt = nil
+
+	_, err := jwt.Verify[jwt.Claims](verifier, sign(t, jwt.HS256, "", secret, claims))
+	assert.Nil(t, err)
+}
+
+func TestNewHMACKeyWeakSecret(t *testing.T) {
+	_, err := jwt.NewHMACKey("", []byte("secret"))
+	assert.ErrorIs(t, err, jwt.ErrWeakSecret)
+}
@@ -0,0 +1,3 @@
+// Package metrics provides counters, gauges and histograms which are
+// exposed in the Prometheus text format by the handler of a [Registry].
+package metrics
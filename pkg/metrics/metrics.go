@@ -0,0 +1,280 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// DefaultBuckets are the upper bounds of the buckets of a [Histogram],
+// in seconds, which are suitable for request durations.
+//
+//nolint:gochecknoglobals //used as default
+var DefaultBuckets = []float64{
+	0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+// Sample is a value of a metric for one combination of label values.
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// CollectFunc returns the current [Sample]s of a metric.
+type CollectFunc = func() []Sample
+
+// Counter is a metric of which the values only increase.
+type Counter struct {
+	family *family
+}
+
+// Inc increases the value of a [Counter] for labelValues by 1.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases the value of a [Counter] for labelValues by value,
+// which can't be negative.
+func (c *Counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		panic("counters can't decrease")
+	}
+
+	c.family.update(labelValues, func(s *series) {
+		s.value += value
+	})
+}
+
+// Gauge is a metric of which the values can go up and down.
+type Gauge struct {
+	family *family
+}
+
+// Set sets the value of a [Gauge] for labelValues.
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.family.update(labelValues, func(s *series) {
+		s.value = value
+	})
+}
+
+// Add adds value, which can be negative, to
+// the value of a [Gauge] for labelValues.
+func (g *Gauge) Add(value float64, labelValues ...string) {
+	g.family.update(labelValues, func(s *series) {
+		s.value += value
+	})
+}
+
+// Histogram is a metric counting observations in buckets,
+// for example request durations.
+type Histogram struct {
+	family *family
+}
+
+// Observe adds an observation to a [Histogram] for labelValues.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.family.update(labelValues, func(s *series) {
+		for i, upperBound := range h.family.buckets {
+			if value <= upperBound {
+				s.buckets[i]++
+			}
+		}
+
+		s.value += value
+		s.count++
+	})
+}
+
+type family struct {
+	name       string
+	help       string
+	metricType string
+	labels     []string
+	buckets    []float64
+	mu         *sync.Mutex
+	series     map[string]*series
+	collectors []CollectFunc
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	buckets     []uint64
+	count       uint64
+}
+
+func newFamily(
+	name string,
+	help string,
+	metricType string,
+	labels []string,
+	buckets []float64,
+) *family {
+	return &family{
+		name:       name,
+		help:       help,
+		metricType: metricType,
+		labels:     labels,
+		buckets:    buckets,
+		mu:         &sync.Mutex{},
+		series:     make(map[string]*series),
+		collectors: []CollectFunc{},
+	}
+}
+
+func (f *family) addCollector(collect CollectFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.collectors = append(f.collectors, collect)
+}
+
+func (f *family) update(labelValues []string, update func(s *series)) {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf(
+			"metric '%s' expects %d label values, got %d",
+			f.name,
+			len(f.labels),
+			len(labelValues),
+		))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{
+			labelValues: slices.Clone(labelValues),
+			value:       0,
+			buckets:     make([]uint64, len(f.buckets)),
+			count:       0,
+		}
+		f.series[key] = s
+	}
+
+	update(s)
+}
+
+// snapshot returns copies of all series, including collected ones,
+// sorted by their label values.
+func (f *family) snapshot() []series {
+	f.mu.Lock()
+	result := make([]series, 0, len(f.series))
+	for _, s := range f.series {
+		copied := *s
+		copied.buckets = slices.Clone(s.buckets)
+		result = append(result, copied)
+	}
+	collectors := slices.Clone(f.collectors)
+	f.mu.Unlock()
+
+	for _, collect := range collectors {
+		for _, sample := range collect() {
+			if len(sample.LabelValues) != len(f.labels) {
+				continue
+			}
+
+			//nolint:exhaustruct //collected series don't have buckets
+			result = append(result, series{
+				labelValues: sample.LabelValues,
+				value:       sample.Value,
+			})
+		}
+	}
+
+	slices.SortFunc(result, func(a series, b series) int {
+		return slices.Compare(a.labelValues, b.labelValues)
+	})
+
+	return result
+}
+
+func (f *family) write(w *countingWriter) {
+	w.WriteString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+	w.WriteString("# TYPE " + f.name + " " + f.metricType + "\n")
+
+	for _, s := range f.snapshot() {
+		if f.metricType != typeHistogram {
+			w.WriteString(
+				f.name + f.labelString(s.labelValues, "") +
+					" " + formatValue(s.value) + "\n",
+			)
+			continue
+		}
+
+		for i, upperBound := range f.buckets {
+			w.WriteString(
+				f.name + "_bucket" +
+					f.labelString(s.labelValues, formatValue(upperBound)) +
+					" " + strconv.FormatUint(s.buckets[i], 10) + "\n",
+			)
+		}
+
+		labels := f.labelString(s.labelValues, "")
+		count := strconv.FormatUint(s.count, 10)
+		w.WriteString(
+			f.name + "_bucket" + f.labelString(s.labelValues, "+Inf") +
+				" " + count + "\n",
+		)
+		w.WriteString(f.name + "_sum" + labels + " " + formatValue(s.value) + "\n")
+		w.WriteString(f.name + "_count" + labels + " " + count + "\n")
+	}
+}
+
+// labelString formats label values as {name="value",...},
+// adding the le label of histogram buckets when it isn't empty.
+func (f *family) labelString(labelValues []string, le string) string {
+	pairs := make([]string, 0, len(labelValues)+1)
+	for i, value := range labelValues {
+		pairs = append(pairs, f.labels[i]+`="`+escapeLabel(value)+`"`)
+	}
+
+	if le != "" {
+		pairs = append(pairs, `le="`+le+`"`)
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+//nolint:gochecknoglobals //replacers are reused
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
+
+func escapeLabel(value string) string {
+	return labelReplacer.Replace(value)
+}
@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Registry holds metrics and writes them in the Prometheus text format.
+// Registering a metric with the name of an existing metric of the
+// same type returns the existing metric, so components can register
+// their metrics more than once. Registering a metric with the name of
+// a metric of another type, or other labels, panics.
+type Registry struct {
+	mu       *sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry creates a new [Registry].
+func NewRegistry() *Registry {
+	return &Registry{
+		mu:       &sync.Mutex{},
+		families: make(map[string]*family),
+	}
+}
+
+// Counter registers a [Counter] with the provided label names.
+func (r *Registry) Counter(name string, help string, labels ...string) *Counter {
+	return &Counter{
+		family: r.register(name, help, typeCounter, labels, nil),
+	}
+}
+
+// Gauge registers a [Gauge] with the provided label names.
+func (r *Registry) Gauge(name string, help string, labels ...string) *Gauge {
+	return &Gauge{
+		family: r.register(name, help, typeGauge, labels, nil),
+	}
+}
+
+// Histogram registers a [Histogram] with the provided upper bounds
+// of its buckets and label names. When buckets is empty,
+// [DefaultBuckets] are used.
+func (r *Registry) Histogram(
+	name string,
+	help string,
+	buckets []float64,
+	labels ...string,
+) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	return &Histogram{
+		family: r.register(name, help, typeHistogram, labels, buckets),
+	}
+}
+
+// CounterFunc registers a counter of which the values are
+// collected by calling collect when the [Registry] is scraped,
+// for example to expose counters kept by another library.
+func (r *Registry) CounterFunc(
+	name string,
+	help string,
+	labels []string,
+	collect CollectFunc,
+) {
+	r.register(name, help, typeCounter, labels, nil).addCollector(collect)
+}
+
+// GaugeFunc registers a gauge of which the values are
+// collected by calling collect when the [Registry] is scraped.
+func (r *Registry) GaugeFunc(
+	name string,
+	help string,
+	labels []string,
+	collect CollectFunc,
+) {
+	r.register(name, help, typeGauge, labels, nil).addCollector(collect)
+}
+
+// Handler returns a [http.Handler] serving the metrics of a [Registry].
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_, _ = r.WriteTo(w)
+	})
+}
+
+// WriteTo writes the metrics of a [Registry] in the Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+
+	slices.SortFunc(families, func(a *family, b *family) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	cw := &countingWriter{w: bufio.NewWriter(w), n: 0}
+	for _, f := range families {
+		f.write(cw)
+	}
+
+	if err := cw.w.Flush(); err != nil {
+		return cw.n, err
+	}
+
+	return cw.n, nil
+}
+
+func (r *Registry) register(
+	name string,
+	help string,
+	metricType string,
+	labels []string,
+	buckets []float64,
+) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.families[name]; ok {
+		if existing.metricType != metricType ||
+			!slices.Equal(existing.labels, labels) ||
+			!slices.Equal(existing.buckets, buckets) {
+			panic(fmt.Sprintf("metric '%s' is already registered differently", name))
+		}
+
+		return existing
+	}
+
+	f := newFamily(name, help, metricType, slices.Clone(labels), buckets)
+	r.families[name] = f
+	return f
+}
+
+type countingWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (w *countingWriter) WriteString(s string) {
+	n, _ := w.w.WriteString(s)
+	w.n += int64(n)
+}
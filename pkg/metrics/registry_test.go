@@ -0,0 +1,135 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/XDoubleU/essentia/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(t *testing.T, registry *metrics.Registry) string {
+	t.Helper()
+
+	server := httptest.NewServer(registry.Handler())
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, metrics.ContentType, res.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(res.Body)
+	assert.Nil(t, err)
+
+	return string(body)
+}
+
+func TestCounter(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	counter := registry.Counter("requests_total", "Total requests.", "code")
+	counter.Inc("200")
+	counter.Add(2, "500")
+	counter.Inc("200")
+
+	assert.Equal(
+		t,
+		"# HELP requests_total Total requests.\n"+
+			"# TYPE requests_total counter\n"+
+			"requests_total{code=\"200\"} 2\n"+
+			"requests_total{code=\"500\"} 2\n",
+		scrape(t, registry),
+	)
+}
+
+func TestGauge(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	gauge := registry.Gauge("temperature", "Current temperature.")
+	gauge.Set(20.5)
+	gauge.Add(-0.5)
+
+	assert.Contains(t, scrape(t, registry), "temperature 20\n")
+}
+
+func TestHistogram(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	histogram := registry.Histogram(
+		"duration_seconds",
+		"Durations.",
+		[]float64{1, 0.1},
+		"route",
+	)
+	histogram.Observe(0.05, "/")
+	histogram.Observe(0.5, "/")
+	histogram.Observe(2, "/")
+
+	assert.Equal(
+		t,
+		"# HELP duration_seconds Durations.\n"+
+			"# TYPE duration_seconds histogram\n"+
+			"duration_seconds_bucket{route=\"/\",le=\"0.1\"} 1\n"+
+			"duration_seconds_bucket{route=\"/\",le=\"1\"} 2\n"+
+			"duration_seconds_bucket{route=\"/\",le=\"+Inf\"} 3\n"+
+			"duration_seconds_sum{route=\"/\"} 2.55\n"+
+			"duration_seconds_count{route=\"/\"} 3\n",
+		scrape(t, registry),
+	)
+}
+
+func TestFuncs(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	collect := func(name string, value float64) metrics.CollectFunc {
+		return func() []metrics.Sample {
+			return []metrics.Sample{
+				{LabelValues: []string{name}, Value: value},
+			}
+		}
+	}
+
+	registry.GaugeFunc("queue_length", "Queue length.", []string{"pool"}, collect("b", 3))
+	registry.GaugeFunc("queue_length", "Queue length.", []string{"pool"}, collect("a", 1))
+	registry.CounterFunc("runs_total", "Runs.", []string{"pool"}, collect("a", 7))
+
+	output := scrape(t, registry)
+	assert.Contains(
+		t,
+		output,
+		"# TYPE queue_length gauge\n"+
+			"queue_length{pool=\"a\"} 1\n"+
+			"queue_length{pool=\"b\"} 3\n",
+	)
+	assert.Contains(t, output, "# TYPE runs_total counter\nruns_total{pool=\"a\"} 7\n")
+}
+
+func TestEscaping(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	registry.Counter("escaped_total", "Back\\slash\nnewline.", "value").
+		Inc("\"quoted\"\n")
+
+	output := scrape(t, registry)
+	assert.Contains(t, output, "# HELP escaped_total Back\\\\slash\\nnewline.\n")
+	assert.Contains(t, output, "escaped_total{value=\"\\\"quoted\\\"\\n\"} 1\n")
+}
+
+func TestRegisterTwice(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	registry.Counter("total", "Total.").Inc()
+	registry.Counter("total", "Total.").Inc()
+
+	assert.Contains(t, scrape(t, registry), "total 2\n")
+	assert.Panics(t, func() {
+		registry.Gauge("total", "Total.")
+	})
+	assert.Panics(t, func() {
+		registry.Counter("total", "Total.").Inc("label")
+	})
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/XDoubleU/essentia/internal/shared"
+	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+	errortools "github.com/XDoubleU/essentia/pkg/errors"
+	"github.com/XDoubleU/essentia/pkg/jwt"
+)
+
+// Authenticate is middleware used to authenticate requests using
+// a JSON Web Token provided as bearer token in the Authorization header.
+// Tokens are verified by the provided [jwt.Verifier] and their claims,
+// decoded into T, are stored in the context. They can be retrieved using
+// [contexttools.Claims]. Requests without a valid token are answered
+// with [httptools.UnauthorizedResponse].
+func Authenticate[T jwt.ClaimsType](verifier *jwt.Verifier) shared.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				httptools.UnauthorizedResponse(
+					w,
+					r,
+					errortools.NewUnauthorizedError(
+						errors.New("missing bearer token"),
+					),
+				)
+				return
+			}
+
+			claims, err := jwt.Verify[T](verifier, token)
+			if err != nil {
+				httptools.UnauthorizedResponse(
+					w,
+					r,
+					errortools.NewUnauthorizedError(err),
+				)
+				return
+			}
+
+			ctx := contexttools.WithClaims(r.Context(), claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireScope is middleware used to only allow requests authenticated
+// by [Authenticate] with a token containing scope. Other requests
+// are answered with [httptools.ForbiddenResponse].
+func RequireScope(scope string) shared.Middleware {
+	return requireClaims(func(claims jwt.Claims) bool {
+		return claims.HasScope(scope)
+	})
+}
+
+// RequireRole is middleware used to only allow requests authenticated
+// by [Authenticate] with a token containing role. Other requests
+// are answered with [httptools.ForbiddenResponse].
+func RequireRole(role string) shared.Middleware {
+	return requireClaims(func(claims jwt.Claims) bool {
+		return claims.HasRole(role)
+	})
+}
+
+func requireClaims(allowed func(claims jwt.Claims) bool) shared.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := contexttools.Claims[jwt.ClaimsType](r.Context())
+			if claims == nil {
+				httptools.UnauthorizedResponse(
+					w,
+					r,
+					errortools.NewUnauthorizedError(
+						errors.New("request isn't authenticated"),
+					),
+				)
+				return
+			}
+
+			if !allowed((*claims).Registered()) {
+				httptools.ForbiddenResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,151 @@
+package middleware_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+	errortools "github.com/XDoubleU/essentia/pkg/errors"
+	"github.com/XDoubleU/essentia/pkg/jwt"
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var authSecret = []byte("a-secret-of-at-least-thirty-two-bytes")
+
+func authVerifier(t *testing.T, issuer string) *jwt.Verifier {
+	t.Helper()
+
+	key, err := jwt.NewHMACKey("", authSecret)
+	require.Nil(t, err)
+
+	return jwt.NewVerifier(jwt.NewKeySet(key), issuer, "", 0)
+}
+
+func signHS256(t *testing.T, claims jwt.Claims) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": "HS256"})
+	require.Nil(t, err)
+
+	payload, err := json.Marshal(claims)
+	require.Nil(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, authSecret)
+	mac.Write([]byte(signed))
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func authRequest(
+	t *testing.T,
+	handler func(next http.Handler) http.Handler,
+	authorization string,
+	innerHandler func(w http.ResponseWriter, r *http.Request),
+) (int, errortools.ErrorDto) {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	res := testMiddleware(t, handler, req, innerHandler)
+
+	var errorDto errortools.ErrorDto
+	if res.Code != http.StatusOK {
+		require.Nil(t, json.NewDecoder(res.Body).Decode(&errorDto))
+	}
+
+	return res.Code, errorDto
+}
+
+func TestAuthenticate(t *testing.T) {
+	verifier := authVerifier(t, "issuer")
+	authenticate := middleware.Authenticate[jwt.Claims](verifier)
+
+	//nolint:exhaustruct //other fields are optional
+	token := signHS256(t, jwt.Claims{
+		Issuer:    "issuer",
+		Subject:   "user",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+
+	status, _ := authRequest(
+		t,
+		authenticate,
+		"Bearer "+token,
+		func(_ http.ResponseWriter, r *http.Request) {
+			claims := contexttools.Claims[jwt.Claims](r.Context())
+			require.NotNil(t, claims)
+			assert.Equal(t, "user", claims.Subject)
+		},
+	)
+	assert.Equal(t, http.StatusOK, status)
+
+	status, errorDto := authRequest(t, authenticate, "", nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, "missing bearer token", errorDto.Message)
+
+	status, errorDto = authRequest(t, authenticate, "Basic "+token, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, "missing bearer token", errorDto.Message)
+
+	//nolint:exhaustruct //other fields are optional
+	expired := signHS256(t, jwt.Claims{
+		Issuer:    "issuer",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+	})
+	status, errorDto = authRequest(t, authenticate, "Bearer "+expired, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, jwt.ErrExpired.Error(), errorDto.Message)
+}
+
+func TestRequireScopeAndRole(t *testing.T) {
+	verifier := authVerifier(t, "")
+
+	//nolint:exhaustruct //other fields are optional
+	token := "Bearer " + signHS256(t, jwt.Claims{
+		Scope:     "read",
+		Roles:     []string{"user"},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+
+	chain := func(requirement func(next http.Handler) http.Handler) func(
+		next http.Handler,
+	) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return middleware.Authenticate[jwt.Claims](verifier)(requirement(next))
+		}
+	}
+
+	status, _ := authRequest(t, chain(middleware.RequireScope("read")), token, nil)
+	assert.Equal(t, http.StatusOK, status)
+
+	status, _ = authRequest(t, chain(middleware.RequireRole("user")), token, nil)
+	assert.Equal(t, http.StatusOK, status)
+
+	status, errorDto := authRequest(
+		t,
+		chain(middleware.RequireScope("write")),
+		token,
+		nil,
+	)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, errortools.MessageForbidden, errorDto.Message)
+
+	status, _ = authRequest(t, chain(middleware.RequireRole("admin")), token, nil)
+	assert.Equal(t, http.StatusForbidden, status)
+
+	status, _ = authRequest(t, middleware.RequireRole("admin"), "", nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
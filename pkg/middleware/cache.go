@@ -0,0 +1,513 @@
+package middleware
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/XDoubleU/essentia/internal/shared"
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/metrics"
+	"github.com/XDoubleU/essentia/pkg/stores"
+)
+
+const cacheTagsContextKey = contexttools.Key("cache_tags")
+
+// CacheHeader is the header telling whether a response
+// was served by [Cache], being HIT or MISS.
+const CacheHeader = "X-Cache"
+
+// CachedResponse is a response stored by a [CacheStore].
+type CachedResponse = stores.CachedResponse
+
+// CacheStore stores the [CachedResponse]s of a [Cache],
+// see [stores.CacheStore].
+type CacheStore = stores.CacheStore
+
+// CacheOption configures a [Cache].
+type CacheOption func(cache *Cache)
+
+// WithVaryHeaders adds the values of the provided request headers
+// to the cache key, for example Accept or Accept-Language.
+func WithVaryHeaders(headers ...string) CacheOption {
+	return func(cache *Cache) {
+		for _, header := range headers {
+			cache.varyHeaders = append(
+				cache.varyHeaders,
+				http.CanonicalHeaderKey(header),
+			)
+		}
+	}
+}
+
+// Cache is used to cache responses to GET and HEAD requests
+// in a [CacheStore], so expensive handlers only run when needed.
+type Cache struct {
+	store       CacheStore
+	varyHeaders []string
+	requests    *metrics.Counter
+}
+
+// NewCache creates a new [Cache].
+func NewCache(store CacheStore, options ...CacheOption) *Cache {
+	cache := &Cache{
+		store:       store,
+		varyHeaders: []string{},
+		requests:    nil,
+	}
+
+	for _, option := range options {
+		option(cache)
+	}
+
+	return cache
+}
+
+// RegisterMetrics records the amount of requests by result, being hit, miss
+// or bypass, in registry. This should be done before the [Cache] is used.
+func (c *Cache) RegisterMetrics(registry *metrics.Registry) {
+	c.requests = registry.Counter(
+		"cache_requests_total",
+		"Total amount of requests handled by the response cache.",
+		"result",
+	)
+}
+
+// Invalidate removes all cached responses having any of the provided tags,
+// for example after the data shown by them was changed.
+func (c *Cache) Invalidate(ctx context.Context, tags ...string) error {
+	return c.store.InvalidateTags(ctx, tags...)
+}
+
+// AddCacheTags adds tags to the response of a request
+// handled by [Cache.Middleware], so it can be invalidated
+// using [Cache.Invalidate].
+func AddCacheTags(ctx context.Context, tags ...string) {
+	responseTags := contexttools.GetValue[*[]string](ctx, cacheTagsContextKey)
+	if responseTags == nil {
+		return
+	}
+
+	**responseTags = append(**responseTags, tags...)
+}
+
+// Middleware is middleware used to cache successful responses for ttl with
+// the provided tags. Responses are keyed by path, the sorted query and the
+// vary headers. The Cache-Control header is honoured: requests with
+// no-store bypass the cache, no-cache or max-age=0 skip stored responses and
+// responses with no-store, no-cache, private or Set-Cookie aren't stored,
+// while their s-maxage or max-age overrides ttl.
+// Requests with a Cookie header bypass the cache unless Cookie is one of the
+// vary headers. Like a shared cache, requests with an Authorization header,
+// which isn't one of the vary headers, only get and store responses
+// which are explicitly public or have s-maxage.
+// Responses get an ETag, based on their body when they don't have one,
+// so requests with a matching If-None-Match get a 304 without a body.
+// Responses are buffered, so Flush and Hijack aren't supported.
+// When the store fails, requests are handled without the cache.
+func (c *Cache) Middleware(ttl time.Duration, tags ...string) shared.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			requestDirectives := parseCacheControl(r.Header.Get("Cache-Control"))
+			if _, ok := requestDirectives["no-store"]; ok {
+				c.record("bypass")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.Header.Get("Cookie") != "" &&
+				!slices.Contains(c.varyHeaders, "Cookie") {
+				c.record("bypass")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			authorized := r.Header.Get("Authorization") != "" &&
+				!slices.Contains(c.varyHeaders, "Authorization")
+			key := c.key(r)
+
+			if !skipsStoredResponse(requestDirectives) {
+				response, err := c.store.Get(r.Context(), key)
+				if err != nil {
+					logCacheError(r, "failed to load cached response", err)
+				}
+
+				if response != nil && (!authorized || sharedWithAuthorization(*response)) {
+					c.record("hit")
+					writeCachedResponse(w, r, *response, "HIT")
+					return
+				}
+			}
+
+			c.record("miss")
+
+			responseTags := slices.Clone(tags)
+			ctx := context.WithValue(r.Context(), cacheTagsContextKey, &responseTags)
+
+			cw := &cacheWriter{
+				header:      make(http.Header),
+				body:        &bytes.Buffer{},
+				status:      http.StatusOK,
+				wroteHeader: false,
+			}
+			next.ServeHTTP(cw, r.WithContext(ctx))
+
+			now := time.Now()
+			//nolint:exhaustruct //expiry is set when the response is stored
+			response := CachedResponse{
+				Status:   cw.status,
+				Header:   cw.header,
+				Body:     cw.body.Bytes(),
+				Tags:     responseTags,
+				StoredAt: now,
+			}
+
+			if response.Header.Get("ETag") == "" && response.Status == http.StatusOK {
+				response.Header.Set("ETag", bodyETag(response.Body))
+			}
+
+			if responseTTL, ok := storableTTL(response, ttl); ok &&
+				r.Method == http.MethodGet &&
+				(!authorized || sharedWithAuthorization(response)) {
+				response.ExpiresAt = now.Add(responseTTL)
+
+				err := c.store.Set(r.Context(), key, response)
+				if err != nil {
+					logCacheError(r, "failed to store response", err)
+				}
+			}
+
+			writeCachedResponse(w, r, response, "MISS")
+		})
+	}
+}
+
+func (c *Cache) record(result string) {
+	if c.requests != nil {
+		c.requests.Inc(result)
+	}
+}
+
+// key identifies a response by path, the sorted query and the vary headers.
+// HEAD requests share the key of GET requests.
+func (c *Cache) key(r *http.Request) string {
+	query := r.URL.Query()
+	for _, values := range query {
+		slices.Sort(values)
+	}
+
+	key := strings.Builder{}
+	key.WriteString(http.MethodGet + " " + r.URL.EscapedPath())
+	key.WriteString("?" + query.Encode())
+
+	for _, header := range c.varyHeaders {
+		key.WriteString("\n" + header + ": ")
+		key.WriteString(url.QueryEscape(strings.Join(r.Header.Values(header), ",")))
+	}
+
+	return key.String()
+}
+
+func logCacheError(r *http.Request, msg string, err error) {
+	contexttools.Logger(r.Context()).ErrorContext(
+		r.Context(),
+		msg,
+		logging.ErrAttr(err),
+	)
+}
+
+// parseCacheControl returns the directives of a Cache-Control header
+// by their lowercase name, with their value if they have one.
+func parseCacheControl(header string) map[string]string {
+	directives := make(map[string]string)
+
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if name == "" {
+			continue
+		}
+
+		directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+	}
+
+	return directives
+}
+
+func skipsStoredResponse(directives map[string]string) bool {
+	if _, ok := directives["no-cache"]; ok {
+		return true
+	}
+
+	maxAge, ok := directives["max-age"]
+	return ok && maxAge == "0"
+}
+
+// storableTTL returns how long a response can be stored,
+// being false when the response can't be stored.
+func storableTTL(response CachedResponse, ttl time.Duration) (time.Duration, bool) {
+	if response.Status != http.StatusOK ||
+		response.Header.Get("Set-Cookie") != "" ||
+		response.Header.Get("Vary") == "*" {
+		return 0, false
+	}
+
+	directives := parseCacheControl(response.Header.Get("Cache-Control"))
+	for _, directive := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := directives[directive]; ok {
+			return 0, false
+		}
+	}
+
+	for _, directive := range []string{"s-maxage", "max-age"} {
+		value, ok := directives[directive]
+		if !ok {
+			continue
+		}
+
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	return ttl, ttl > 0
+}
+
+// sharedWithAuthorization reports whether a response to a request with
+// an Authorization header can be shared, as defined by RFC 9111 section 3.5.
+func sharedWithAuthorization(response CachedResponse) bool {
+	directives := parseCacheControl(response.Header.Get("Cache-Control"))
+	for _, directive := range []string{"public", "s-maxage"} {
+		if _, ok := directives[directive]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func bodyETag(body []byte) string {
+	hash := sha256.Sum256(body)
+	//nolint:mnd //128 bits is plenty for an ETag
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// matchesETag compares an If-None-Match header to an ETag
+// using the weak comparison, as required for If-None-Match.
+func matchesETag(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeCachedResponse(
+	w http.ResponseWriter,
+	r *http.Request,
+	response CachedResponse,
+	result string,
+) {
+	header := w.Header()
+	for key, values := range response.Header {
+		header[key] = slices.Clone(values)
+	}
+	header.Set(CacheHeader, result)
+
+	if result == "HIT" {
+		age := time.Since(response.StoredAt)
+		header.Set("Age", strconv.Itoa(int(max(age, 0).Seconds())))
+	}
+
+	if response.Status == http.StatusOK &&
+		matchesETag(r.Header.Get("If-None-Match"), response.Header.Get("ETag")) {
+		header.Del("Content-Length")
+		header.Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(response.Status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(response.Body)
+	}
+}
+
+// cacheWriter buffers the response of a handler.
+type cacheWriter struct {
+	header      http.Header
+	body        *bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+// Header returns the headers of the buffered response.
+func (cw *cacheWriter) Header() http.Header {
+	return cw.header
+}
+
+// Write buffers data.
+func (cw *cacheWriter) Write(data []byte) (int, error) {
+	cw.wroteHeader = true
+	return cw.body.Write(data)
+}
+
+// WriteHeader sets the status of the buffered response.
+func (cw *cacheWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+
+	cw.wroteHeader = true
+	cw.status = status
+}
+
+// MemoryCacheStore is a [CacheStore] storing responses in memory.
+// When it's full, the least recently used response is removed.
+type MemoryCacheStore struct {
+	mu         *sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	recency    *list.List
+	tags       map[string]map[string]struct{}
+}
+
+type memoryCacheEntry struct {
+	key      string
+	response CachedResponse
+}
+
+// NewMemoryCacheStore creates a new [MemoryCacheStore]
+// holding at most maxEntries responses.
+func NewMemoryCacheStore(maxEntries int) *MemoryCacheStore {
+	return &MemoryCacheStore{
+		mu:         &sync.Mutex{},
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		recency:    list.New(),
+		tags:       make(map[string]map[string]struct{}),
+	}
+}
+
+// Get returns a stored response.
+func (s *MemoryCacheStore) Get(
+	_ context.Context,
+	key string,
+) (*CachedResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		//nolint:nilnil //a missing response isn't an error
+		return nil, nil
+	}
+
+	//nolint:errcheck //list only contains entries
+	entry := element.Value.(*memoryCacheEntry)
+	if !time.Now().Before(entry.response.ExpiresAt) {
+		s.remove(element)
+		//nolint:nilnil //an expired response isn't an error
+		return nil, nil
+	}
+
+	s.recency.MoveToFront(element)
+
+	response := entry.response
+	return &response, nil
+}
+
+// Set stores a response, removing the least recently used response when full.
+func (s *MemoryCacheStore) Set(
+	_ context.Context,
+	key string,
+	response CachedResponse,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		s.remove(element)
+	}
+
+	s.entries[key] = s.recency.PushFront(&memoryCacheEntry{
+		key:      key,
+		response: response,
+	})
+
+	for _, tag := range response.Tags {
+		if s.tags[tag] == nil {
+			s.tags[tag] = make(map[string]struct{})
+		}
+		s.tags[tag][key] = struct{}{}
+	}
+
+	for s.maxEntries > 0 && s.recency.Len() > s.maxEntries {
+		s.remove(s.recency.Back())
+	}
+
+	return nil
+}
+
+// InvalidateTags removes all responses having any of the provided tags.
+func (s *MemoryCacheStore) InvalidateTags(_ context.Context, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			if element, ok := s.entries[key]; ok {
+				s.remove(element)
+			}
+		}
+	}
+
+	return nil
+}
+
+// remove removes a response, the caller should hold the lock.
+func (s *MemoryCacheStore) remove(element *list.Element) {
+	//nolint:errcheck //list only contains entries
+	entry := s.recency.Remove(element).(*memoryCacheEntry)
+	delete(s.entries, entry.key)
+
+	for _, tag := range entry.response.Tags {
+		delete(s.tags[tag], entry.key)
+		if len(s.tags[tag]) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
+
+// Len returns the amount of responses stored in a [MemoryCacheStore].
+func (s *MemoryCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
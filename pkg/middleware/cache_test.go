@@ -0,0 +1,245 @@
+package middleware_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/XDoubleU/essentia/pkg/metrics"
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cacheRequest(
+	handler http.Handler,
+	method string,
+	target string,
+	header http.Header,
+) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "http://example.com"+target, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	return res
+}
+
+func countingHandler(calls *int, cacheControl string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		middleware.AddCacheTags(r.Context(), "user:"+r.URL.Query().Get("id"))
+
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = fmt.Fprintf(w, "call %d", *calls)
+	})
+}
+
+func TestCache(t *testing.T) {
+	cache := middleware.NewCache(middleware.NewMemoryCacheStore(10))
+	registry := metrics.NewRegistry()
+	cache.RegisterMetrics(registry)
+
+	calls := 0
+	handler := cache.Middleware(time.Minute, "users")(countingHandler(&calls, ""))
+
+	res := cacheRequest(handler, http.MethodGet, "/users?id=1&b=2&b=1", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "MISS", res.Header().Get(middleware.CacheHeader))
+	assert.Equal(t, "call 1", res.Body.String())
+
+	etag := res.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	res = cacheRequest(handler, http.MethodGet, "/users?b=1&b=2&id=1", nil)
+	assert.Equal(t, "HIT", res.Header().Get(middleware.CacheHeader))
+	assert.Equal(t, "call 1", res.Body.String())
+	assert.Equal(t, "0", res.Header().Get("Age"))
+
+	res = cacheRequest(handler, http.MethodHead, "/users?id=1&b=1&b=2", nil)
+	assert.Equal(t, "HIT", res.Header().Get(middleware.CacheHeader))
+	assert.Empty(t, res.Body.String())
+
+	res = cacheRequest(handler, http.MethodGet, "/users?id=1&b=1&b=2",
+		http.Header{"If-None-Match": []string{"W/" + etag}})
+	assert.Equal(t, http.StatusNotModified, res.Code)
+	assert.Empty(t, res.Body.String())
+
+	res = cacheRequest(handler, http.MethodGet, "/users?id=2", nil)
+	assert.Equal(t, "call 2", res.Body.String())
+
+	res = cacheRequest(handler, http.MethodPost, "/users?id=2", nil)
+	assert.Empty(t, res.Header().Get(middleware.CacheHeader))
+	assert.Equal(t, "call 3", res.Body.String())
+
+	output := scrapeMetrics(registry)
+	assert.Contains(t, output, `cache_requests_total{result="hit"} 3`)
+	assert.Contains(t, output, `cache_requests_total{result="miss"} 2`)
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	store := middleware.NewMemoryCacheStore(10)
+	cache := middleware.NewCache(store)
+
+	calls := 0
+	handler := cache.Middleware(time.Minute, "users")(countingHandler(&calls, ""))
+
+	cacheRequest(handler, http.MethodGet, "/users?id=1", nil)
+	cacheRequest(handler, http.MethodGet, "/users?id=2", nil)
+	assert.Equal(t, 2, store.Len())
+
+	require.Nil(t, cache.Invalidate(context.Background(), "user:1"))
+	assert.Equal(t, 1, store.Len())
+
+	res := cacheRequest(handler, http.MethodGet, "/users?id=1", nil)
+	assert.Equal(t, "call 3", res.Body.String())
+
+	require.Nil(t, cache.Invalidate(context.Background(), "users"))
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestCacheControl(t *testing.T) {
+	cache := middleware.NewCache(
+		middleware.NewMemoryCacheStore(10),
+		middleware.WithVaryHeaders("accept-language"),
+	)
+
+	calls := 0
+	handler := cache.Middleware(time.Minute)(countingHandler(&calls, ""))
+
+	cacheRequest(handler, http.MethodGet, "/", nil)
+
+	res := cacheRequest(handler, http.MethodGet, "/",
+		http.Header{"Cache-Control": []string{"no-cache"}})
+	assert.Equal(t, "call 2", res.Body.String())
+
+	res = cacheRequest(handler, http.MethodGet, "/", nil)
+	assert.Equal(t, "call 2", res.Body.String())
+
+	res = cacheRequest(handler, http.MethodGet, "/",
+		http.Header{"Cache-Control": []string{"no-store"}})
+	assert.Equal(t, "call 3", res.Body.String())
+
+	res = cacheRequest(handler, http.MethodGet, "/",
+		http.Header{"Accept-Language": []string{"nl"}})
+	assert.Equal(t, "call 4", res.Body.String())
+
+	calls = 0
+	handler = cache.Middleware(time.Minute)(countingHandler(&calls, "private"))
+
+	cacheRequest(handler, http.MethodGet, "/private", nil)
+	res = cacheRequest(handler, http.MethodGet, "/private", nil)
+	assert.Equal(t, "call 2", res.Body.String())
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	ctx := context.Background()
+	store := middleware.NewMemoryCacheStore(2)
+
+	response := func(expiresIn time.Duration) middleware.CachedResponse {
+		return middleware.CachedResponse{
+			Status:    http.StatusOK,
+			Header:    http.Header{},
+			Body:      []byte("test"),
+			Tags:      []string{"tag"},
+			StoredAt:  time.Now(),
+			ExpiresAt: time.Now().Add(expiresIn),
+		}
+	}
+
+	require.Nil(t, store.Set(ctx, "a", response(time.Minute)))
+	require.Nil(t, store.Set(ctx, "b", response(time.Minute)))
+
+	loaded, err := store.Get(ctx, "a")
+	require.Nil(t, err)
+	assert.NotNil(t, loaded)
+
+	require.Nil(t, store.Set(ctx, "c", response(time.Minute)))
+	assert.Equal(t, 2, store.Len())
+
+	loaded, err = store.Get(ctx, "b")
+	require.Nil(t, err)
+	assert.Nil(t, loaded)
+
+	require.Nil(t, store.Set(ctx, "d", response(-time.Minute)))
+	loaded, err = store.Get(ctx, "d")
+	require.Nil(t, err)
+	assert.Nil(t, loaded)
+	assert.Equal(t, 1, store.Len())
+}
+
+func userHandler(calls *int, cacheControl string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+
+		user := r.Header.Get("Authorization")
+		if cookie, err := r.Cookie("session"); err == nil {
+			user = cookie.Value
+		}
+
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		_, _ = fmt.Fprintf(w, "hello %s", user)
+	})
+}
+
+func TestCacheCookies(t *testing.T) {
+	store := middleware.NewMemoryCacheStore(10)
+	cache := middleware.NewCache(store)
+
+	calls := 0
+	handler := cache.Middleware(time.Minute)(userHandler(&calls, ""))
+
+	for _, user := range []string{"alice", "bob"} {
+		res := cacheRequest(handler, http.MethodGet, "/me",
+			http.Header{"Cookie": []string{"session=" + user}})
+		assert.Equal(t, "hello "+user, res.Body.String())
+		assert.Empty(t, res.Header().Get(middleware.CacheHeader))
+	}
+	assert.Equal(t, 0, store.Len())
+
+	cache = middleware.NewCache(store, middleware.WithVaryHeaders("Cookie"))
+	handler = cache.Middleware(time.Minute)(userHandler(&calls, ""))
+
+	for _, user := range []string{"alice", "bob", "alice"} {
+		res := cacheRequest(handler, http.MethodGet, "/me",
+			http.Header{"Cookie": []string{"session=" + user}})
+		assert.Equal(t, "hello "+user, res.Body.String())
+	}
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, 2, store.Len())
+}
+
+func TestCacheAuthorization(t *testing.T) {
+	store := middleware.NewMemoryCacheStore(10)
+	cache := middleware.NewCache(store)
+
+	calls := 0
+	handler := cache.Middleware(time.Minute)(userHandler(&calls, ""))
+
+	for _, user := range []string{"alice", "bob"} {
+		res := cacheRequest(handler, http.MethodGet, "/me",
+			http.Header{"Authorization": []string{user}})
+		assert.Equal(t, "hello "+user, res.Body.String())
+	}
+	assert.Equal(t, 0, store.Len())
+
+	handler = cache.Middleware(time.Minute)(userHandler(&calls, "public"))
+
+	for range 2 {
+		res := cacheRequest(handler, http.MethodGet, "/public",
+			http.Header{"Authorization": []string{"alice"}})
+		assert.Equal(t, "hello alice", res.Body.String())
+	}
+	assert.Equal(t, 3, calls)
+}
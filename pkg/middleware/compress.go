@@ -0,0 +1,419 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/XDoubleU/essentia/internal/shared"
+)
+
+// Encodings supported by [Compress]. The standard library has
+// no brotli or zstd encoder, so these aren't supported.
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+)
+
+const defaultCompressMinSize = 1024
+
+// DefaultCompressContentTypes are the content types compressed by
+// [Compress] by default. A type ending with "/*" matches all its subtypes.
+//
+//nolint:gochecknoglobals //used as constant
+var DefaultCompressContentTypes = []string{
+	"text/*",
+	"application/json",
+	"application/problem+json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+}
+
+// CompressOption configures [Compress].
+type CompressOption func(options *compressOptions)
+
+type compressOptions struct {
+	minSize      int
+	level        int
+	contentTypes []string
+}
+
+// WithMinSize sets the minimum size in bytes of responses to compress,
+// being 1024 by default. Smaller responses are sent uncompressed,
+// unless they are flushed before being finished.
+func WithMinSize(size int) CompressOption {
+	return func(options *compressOptions) {
+		options.minSize = size
+	}
+}
+
+// WithCompressionLevel sets the level used by the encoders,
+// being [gzip.DefaultCompression] by default.
+func WithCompressionLevel(level int) CompressOption {
+	return func(options *compressOptions) {
+		options.level = level
+	}
+}
+
+// WithContentTypes sets the content types to compress, replacing
+// [DefaultCompressContentTypes].
+func WithContentTypes(contentTypes ...string) CompressOption {
+	return func(options *compressOptions) {
+		options.contentTypes = contentTypes
+	}
+}
+
+// encoder is implemented by both [gzip.Writer] and [zlib.Writer].
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Compress is middleware used to compress responses using gzip or deflate,
+// as negotiated using the Accept-Encoding header. Only responses of
+// the allowed content types and at least the minimum size are compressed,
+// "Accept-Encoding" is always added to the Vary header.
+// Flushing a response sends the data compressed so far and hijacking
+// the connection, for example to upgrade to a websocket, is supported.
+func Compress(options ...CompressOption) (shared.Middleware, error) {
+	compressOptions := compressOptions{
+		minSize:      defaultCompressMinSize,
+		level:        gzip.DefaultCompression,
+		contentTypes: DefaultCompressContentTypes,
+	}
+
+	for _, option := range options {
+		option(&compressOptions)
+	}
+
+	// validate the level once, so creating encoders can't fail later on
+	if _, err := gzip.NewWriterLevel(io.Discard, compressOptions.level); err != nil {
+		return nil, err
+	}
+
+	pools := map[string]*sync.Pool{
+		EncodingGzip: {New: func() any {
+			//nolint:errcheck //level is validated
+			w, _ := gzip.NewWriterLevel(io.Discard, compressOptions.level)
+			return w
+		}},
+		EncodingDeflate: {New: func() any {
+			//nolint:errcheck //level is validated
+			w, _ := zlib.NewWriterLevel(io.Discard, compressOptions.level)
+			return w
+		}},
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			addVary(w.Header(), "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead ||
+				r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				options:        compressOptions,
+				encoding:       encoding,
+				pool:           pools[encoding],
+				encoder:        nil,
+				buffer:         []byte{},
+				status:         http.StatusOK,
+				wroteHeader:    false,
+				decided:        false,
+				hijacked:       false,
+			}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}, nil
+}
+
+// addVary adds value to the Vary header when it isn't present yet.
+func addVary(header http.Header, value string) {
+	for _, vary := range header.Values("Vary") {
+		for _, field := range strings.Split(vary, ",") {
+			field = strings.TrimSpace(field)
+			if field == "*" || strings.EqualFold(field, value) {
+				return
+			}
+		}
+	}
+
+	header.Add("Vary", value)
+}
+
+// negotiateEncoding returns the supported encoding with the highest
+// quality in an Accept-Encoding header, preferring gzip on a tie.
+func negotiateEncoding(acceptEncoding string) string {
+	best := ""
+	bestQuality := 0.0
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		if name == "*" {
+			name = EncodingGzip
+		}
+
+		if quality <= 0 || (name != EncodingGzip && name != EncodingDeflate) {
+			continue
+		}
+
+		if quality > bestQuality ||
+			(quality == bestQuality && name == EncodingGzip) {
+			best = name
+			bestQuality = quality
+		}
+	}
+
+	return best
+}
+
+// compressWriter buffers the start of a response until it knows whether
+// the response should be compressed, after which it writes through.
+type compressWriter struct {
+	http.ResponseWriter
+	options     compressOptions
+	encoding    string
+	pool        *sync.Pool
+	encoder     encoder
+	buffer      []byte
+	status      int
+	wroteHeader bool
+	decided     bool
+	hijacked    bool
+}
+
+// WriteHeader sets the status of the response, which is sent
+// once it is known whether the response is compressed.
+func (cw *compressWriter) WriteHeader(status int) {
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	if cw.wroteHeader {
+		return
+	}
+
+	cw.wroteHeader = true
+	cw.status = status
+
+	if !cw.compressible(false) {
+		//nolint:errcheck //nothing is buffered yet, so nothing is written
+		_ = cw.decide(false)
+	}
+}
+
+// Write compresses data when the response is compressed,
+// otherwise data is buffered until the minimum size is reached.
+func (cw *compressWriter) Write(data []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if cw.decided {
+		if cw.encoder != nil {
+			return cw.encoder.Write(data)
+		}
+
+		return cw.ResponseWriter.Write(data)
+	}
+
+	cw.buffer = append(cw.buffer, data...)
+	if len(cw.buffer) < cw.options.minSize {
+		return len(data), nil
+	}
+
+	if err := cw.decide(cw.compressible(true)); err != nil {
+		return 0, err
+	}
+
+	return len(data), nil
+}
+
+// Flush sends the data written so far, compressed when
+// the content type is allowed regardless of its size.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if !cw.wroteHeader {
+			cw.WriteHeader(http.StatusOK)
+		}
+
+		if err := cw.decide(cw.compressible(true)); err != nil {
+			return
+		}
+	}
+
+	if cw.encoder != nil {
+		if err := cw.encoder.Flush(); err != nil {
+			return
+		}
+	}
+
+	//nolint:errcheck //flushing isn't supported by every writer
+	_ = http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Hijack lets the caller take over the connection,
+// after which nothing is written by the [compressWriter].
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(cw.ResponseWriter).Hijack()
+	if err == nil {
+		cw.hijacked = true
+	}
+
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying [http.ResponseWriter],
+// as used by [http.ResponseController].
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// compressible reports whether the response should be compressed.
+// The content type is sniffed from the buffer when it isn't set yet
+// and final is true.
+func (cw *compressWriter) compressible(final bool) bool {
+	header := cw.Header()
+
+	if cw.status < http.StatusOK ||
+		cw.status == http.StatusNoContent ||
+		cw.status == http.StatusNotModified ||
+		header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	if length := header.Get("Content-Length"); length != "" {
+		size, err := strconv.Atoi(length)
+		if err == nil && size < cw.options.minSize {
+			return false
+		}
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		if !final {
+			return true
+		}
+
+		contentType = http.DetectContentType(cw.buffer)
+		header.Set("Content-Type", contentType)
+	}
+
+	return cw.allowedContentType(contentType)
+}
+
+func (cw *compressWriter) allowedContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range cw.options.contentTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok {
+			if strings.HasPrefix(mediaType, prefix) {
+				return true
+			}
+			continue
+		}
+
+		if mediaType == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decide sends the headers of the response, compressed or not,
+// followed by the buffered data.
+func (cw *compressWriter) decide(compress bool) error {
+	cw.decided = true
+	header := cw.Header()
+
+	if compress {
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		header.Del("Accept-Ranges")
+
+		// the compressed representation isn't byte-for-byte identical
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+
+		//nolint:errcheck //pool only contains encoders
+		cw.encoder = cw.pool.Get().(encoder)
+		cw.encoder.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buffer := cw.buffer
+	cw.buffer = nil
+
+	if len(buffer) == 0 {
+		return nil
+	}
+
+	var err error
+	if cw.encoder != nil {
+		_, err = cw.encoder.Write(buffer)
+	} else {
+		_, err = cw.ResponseWriter.Write(buffer)
+	}
+
+	return err
+}
+
+// close sends the remaining buffered data and finishes the compressed stream.
+func (cw *compressWriter) close() {
+	if cw.hijacked {
+		return
+	}
+
+	if !cw.decided {
+		if !cw.wroteHeader && len(cw.buffer) == 0 {
+			return
+		}
+
+		if cw.decide(false) != nil {
+			return
+		}
+	}
+
+	if cw.encoder == nil {
+		return
+	}
+
+	if cw.encoder.Close() == nil {
+		cw.encoder.Reset(io.Discard)
+		cw.pool.Put(cw.encoder)
+	}
+}
@@ -0,0 +1,209 @@
+package middleware_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func compressHandler(
+	t *testing.T,
+	contentType string,
+	body string,
+	options ...middleware.CompressOption,
+) http.Handler {
+	t.Helper()
+
+	compress, err := middleware.Compress(options...)
+	require.Nil(t, err)
+
+	return compress(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if contentType != "" {
+				w.Header().Set("Content-Type", contentType)
+			}
+			w.Header().Set("ETag", `"test"`)
+			_, _ = w.Write([]byte(body))
+		}),
+	)
+}
+
+func compressRequest(
+	handler http.Handler,
+	acceptEncoding string,
+) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	return res
+}
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat(`{"value":"test"}`, 100)
+	handler := compressHandler(t, "application/json", body)
+
+	res := compressRequest(handler, "deflate;q=0.5, gzip")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "gzip", res.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", res.Header().Get("Vary"))
+	assert.Equal(t, `W/"test"`, res.Header().Get("ETag"))
+
+	reader, err := gzip.NewReader(res.Body)
+	require.Nil(t, err)
+	data, err := io.ReadAll(reader)
+	require.Nil(t, err)
+	assert.Equal(t, body, string(data))
+
+	res = compressRequest(handler, "gzip;q=0.5, deflate")
+	assert.Equal(t, "deflate", res.Header().Get("Content-Encoding"))
+
+	zlibReader, err := zlib.NewReader(res.Body)
+	require.Nil(t, err)
+	data, err = io.ReadAll(zlibReader)
+	require.Nil(t, err)
+	assert.Equal(t, body, string(data))
+
+	res = compressRequest(handler, "br, gzip;q=0")
+	assert.Empty(t, res.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", res.Header().Get("Vary"))
+	assert.Equal(t, body, res.Body.String())
+}
+
+func TestCompressSkipped(t *testing.T) {
+	body := strings.Repeat("a", 2048)
+
+	res := compressRequest(compressHandler(t, "text/plain", "small"), "gzip")
+	assert.Empty(t, res.Header().Get("Content-Encoding"))
+	assert.Equal(t, "small", res.Body.String())
+
+	res = compressRequest(compressHandler(t, "image/png", body), "gzip")
+	assert.Empty(t, res.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, res.Body.String())
+
+	res = compressRequest(compressHandler(t, "", body), "gzip")
+	assert.Equal(t, "gzip", res.Header().Get("Content-Encoding"))
+	assert.Equal(t, "text/plain; charset=utf-8", res.Header().Get("Content-Type"))
+
+	res = compressRequest(
+		compressHandler(
+			t,
+			"text/plain",
+			body,
+			middleware.WithContentTypes("application/json"),
+		),
+		"gzip",
+	)
+	assert.Empty(t, res.Header().Get("Content-Encoding"))
+
+	res = compressRequest(
+		compressHandler(t, "text/plain", "small", middleware.WithMinSize(0)),
+		"gzip",
+	)
+	assert.Equal(t, "gzip", res.Header().Get("Content-Encoding"))
+}
+
+func TestCompressInvalidLevel(t *testing.T) {
+	_, err := middleware.Compress(middleware.WithCompressionLevel(42))
+	assert.NotNil(t, err)
+}
+
+func TestCompressFlush(t *testing.T) {
+	compress, err := middleware.Compress()
+	require.Nil(t, err)
+
+	flushed := make(chan struct{})
+	handler := compress(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: first\n\n"))
+			w.(http.Flusher).Flush()
+			<-flushed
+			_, _ = w.Write([]byte("data: second\n\n"))
+		}),
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			handler.ServeHTTP(httptools.NewResponseWriter(w), r)
+		},
+	))
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	res, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+
+	reader, err := gzip.NewReader(res.Body)
+	require.Nil(t, err)
+
+	lines := bufio.NewReader(reader)
+	line, err := lines.ReadString('\n')
+	require.Nil(t, err)
+	assert.Equal(t, "data: first\n", line)
+
+	close(flushed)
+
+	data, err := io.ReadAll(lines)
+	require.Nil(t, err)
+	assert.Equal(t, "\ndata: second\n\n", string(data))
+}
+
+func TestCompressHijack(t *testing.T) {
+	compress, err := middleware.Compress(middleware.WithMinSize(0))
+	require.Nil(t, err)
+
+	handler := compress(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			conn, rw, hijackErr := http.NewResponseController(w).Hijack()
+			if hijackErr != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			defer conn.Close()
+
+			_, _ = rw.WriteString(
+				"HTTP/1.1 200 OK\r\nContent-Length: 8\r\n" +
+					"Connection: close\r\n\r\nhijacked",
+			)
+			_ = rw.Flush()
+		}),
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			handler.ServeHTTP(httptools.NewResponseWriter(w), r)
+		},
+	))
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	res, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Empty(t, res.Header.Get("Content-Encoding"))
+	assert.Equal(t, "hijacked", string(data))
+}
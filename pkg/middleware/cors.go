@@ -5,20 +5,27 @@ import (
 	"github.com/rs/cors"
 )
 
-// CORS is middleware used to apply CORS settings.
+// CORS is middleware used to apply CORS settings,
+// being those of [DefaultCORSOptions].
 func CORS(allowedOrigins []string, useSentry bool) shared.Middleware {
-	allowedHeaders := []string{"content-type"}
+	return cors.New(DefaultCORSOptions(allowedOrigins, useSentry)).Handler
+}
+
+// DefaultCORSOptions returns the [cors.Options] used by [CORS], which
+// can be adjusted and used with [WithCORS]. Credentials and the traceparent
+// header used by [Tracing] are allowed. The headers needed by Sentry
+// are allowed when useSentry is true.
+func DefaultCORSOptions(allowedOrigins []string, useSentry bool) cors.Options {
+	allowedHeaders := []string{"content-type", "authorization", "traceparent"}
 	if useSentry {
 		allowedHeaders = append(allowedHeaders, "baggage", "sentry-trace")
 	}
 
 	//nolint:exhaustruct //other fields are optional
-	cors := cors.New(cors.Options{
+	return cors.Options{
 		AllowedOrigins:   allowedOrigins,
 		AllowCredentials: true,
-		AllowedMethods:   []string{"GET", "POST", "PATCH", "DELETE"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
 		AllowedHeaders:   allowedHeaders,
-	})
-
-	return cors.Handler
+	}
 }
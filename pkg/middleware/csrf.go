@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/XDoubleU/essentia/internal/shared"
+	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+	"github.com/XDoubleU/essentia/pkg/session"
+)
+
+const (
+	// CSRFFieldName is the name of the form field containing the CSRF token.
+	CSRFFieldName = "csrf_token"
+	// CSRFHeader is the header which can contain the CSRF token.
+	CSRFHeader = "X-CSRF-Token"
+
+	csrfSessionKey  = "csrf_token"
+	csrfTokenLength = 32
+)
+
+// CSRF is middleware used to protect against cross-site request forgery.
+// It uses a token per [session.Session], so it should be used after
+// [session.Manager.Middleware]. The token is created by [CSRFToken]
+// and [CSRFTemplateField]. Requests with unsafe methods have to provide
+// the token using the [CSRFFieldName] form field or the [CSRFHeader] header.
+// When they don't, their Origin or Referer header has to match the scheme
+// and host of the request or one of trustedOrigins instead. Cross-origin
+// requests from other origins are always rejected. Behind a proxy
+// terminating TLS, the https origin of the application should be trusted.
+// Failures are answered with [httptools.ForbiddenResponse].
+func CSRF(trustedOrigins []string) shared.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s := session.FromContext(r.Context())
+			if s == nil {
+				httptools.ServerErrorResponse(
+					w,
+					r,
+					errors.New("CSRF requires session middleware"),
+				)
+				return
+			}
+
+			if isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !checkOrigin(r, trustedOrigins) {
+				httptools.ForbiddenResponse(w, r)
+				return
+			}
+
+			provided := r.Header.Get(CSRFHeader)
+			if provided == "" {
+				provided = r.PostFormValue(CSRFFieldName)
+			}
+
+			if provided == "" && !hasOrigin(r) {
+				httptools.ForbiddenResponse(w, r)
+				return
+			}
+
+			token, _ := session.Get[string](s, csrfSessionKey)
+			if provided != "" &&
+				subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				httptools.ForbiddenResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CSRFToken returns the CSRF token of the session of a request, which
+// is created when the session doesn't have one yet. As the session is saved
+// when the response is started, this should be called before writing it,
+// for example while building the data of a template.
+func CSRFToken(r *http.Request) string {
+	s := session.FromContext(r.Context())
+	if s == nil {
+		return ""
+	}
+
+	token, err := csrfToken(s)
+	if err != nil {
+		return ""
+	}
+
+	return token
+}
+
+// CSRFTemplateField returns a hidden input containing the CSRF token
+// of the session of a request, which can be used in html/template forms.
+// Like [CSRFToken], this should be called before writing the response.
+func CSRFTemplateField(r *http.Request) template.HTML {
+	//nolint:gosec //the token is escaped
+	return template.HTML(fmt.Sprintf(
+		`<input type="hidden" name="%s" value="%s">`,
+		CSRFFieldName,
+		template.HTMLEscapeString(CSRFToken(r)),
+	))
+}
+
+func csrfToken(s *session.Session) (string, error) {
+	if token, ok := session.Get[string](s, csrfSessionKey); ok {
+		return token, nil
+	}
+
+	data := make([]byte, csrfTokenLength)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(data)
+	return token, s.Set(csrfSessionKey, token)
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet ||
+		method == http.MethodHead ||
+		method == http.MethodOptions ||
+		method == http.MethodTrace
+}
+
+func hasOrigin(r *http.Request) bool {
+	return r.Header.Get("Origin") != "" || r.Referer() != ""
+}
+
+// checkOrigin checks if the Origin or otherwise the Referer header
+// matches the scheme and host of the request or a trusted origin,
+// when present.
+func checkOrigin(r *http.Request, trustedOrigins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = r.Referer()
+	}
+
+	if origin == "" {
+		return true
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+
+	origin = parsed.Scheme + "://" + parsed.Host
+	return origin == requestOrigin(r) || slices.Contains(trustedOrigins, origin)
+}
+
+// requestOrigin returns the origin of the request itself.
+func requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
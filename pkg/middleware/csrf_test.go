@@ -0,0 +1,181 @@
+package middleware_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	"github.com/XDoubleU/essentia/pkg/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type csrfTester struct {
+	t       *testing.T
+	handler http.Handler
+	cookie  *http.Cookie
+	token   string
+}
+
+func newCSRFTester(t *testing.T) *csrfTester {
+	t.Helper()
+
+	store, err := session.NewCookieStore([]byte("0123456789abcdef"))
+	require.Nil(t, err)
+
+	tester := &csrfTester{t: t, handler: nil, cookie: nil, token: ""}
+
+	tmpl := template.Must(template.New("form").Parse(`{{ .Field }}`))
+	tester.handler = session.NewManager(store).Middleware()(
+		middleware.CSRF([]string{"https://trusted.com"})(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tester.token = middleware.CSRFToken(r)
+				_ = tmpl.Execute(w, map[string]any{
+					"Field": middleware.CSRFTemplateField(r),
+				})
+			}),
+		),
+	)
+
+	res := tester.do(http.MethodGet, nil, nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), `name="csrf_token"`)
+	assert.Contains(t, res.Body.String(), tester.token)
+	require.NotEmpty(t, tester.token)
+
+	tester.cookie = res.Result().Cookies()[0]
+
+	return tester
+}
+
+func (tester *csrfTester) do(
+	method string,
+	form url.Values,
+	headers map[string]string,
+) *httptest.ResponseRecorder {
+	tester.t.Helper()
+
+	req, _ := http.NewRequest(
+		method,
+		"http://example.com/foo",
+		strings.NewReader(form.Encode()),
+	)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if tester.cookie != nil {
+		req.AddCookie(tester.cookie)
+	}
+
+	res := httptest.NewRecorder()
+	tester.handler.ServeHTTP(res, req)
+
+	return res
+}
+
+func TestCSRF(t *testing.T) {
+	tester := newCSRFTester(t)
+	token := tester.token
+
+	tests := []struct {
+		name     string
+		form     url.Values
+		headers  map[string]string
+		expected int
+	}{
+		{
+			name:     "form token",
+			form:     url.Values{middleware.CSRFFieldName: {token}},
+			headers:  map[string]string{},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "header token",
+			form:     url.Values{},
+			headers:  map[string]string{middleware.CSRFHeader: token},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "invalid token",
+			form:     url.Values{middleware.CSRFFieldName: {"invalid"}},
+			headers:  map[string]string{},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "missing token",
+			form:     url.Values{},
+			headers:  map[string]string{},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "same origin",
+			form:     url.Values{},
+			headers:  map[string]string{"Origin": "http://example.com"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "same referer",
+			form:     url.Values{},
+			headers:  map[string]string{"Referer": "http://example.com/form"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "other scheme",
+			form:     url.Values{},
+			headers:  map[string]string{"Origin": "https://example.com"},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "trusted origin",
+			form:     url.Values{},
+			headers:  map[string]string{"Origin": "https://trusted.com"},
+			expected: http.StatusOK,
+		},
+		{
+			name: "cross origin",
+			form: url.Values{middleware.CSRFFieldName: {token}},
+			headers: map[string]string{
+				"Origin": "https://evil.com",
+			},
+			expected: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tester.do(http.MethodPost, tt.form, tt.headers)
+			assert.Equal(t, tt.expected, res.Code)
+		})
+	}
+
+	assert.Equal(t, token, tester.token)
+}
+
+func TestCSRFWithoutSession(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	res := testMiddleware(t, middleware.CSRF([]string{}), req, nil)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+}
+
+func TestCSRFLazyToken(t *testing.T) {
+	store, err := session.NewCookieStore([]byte("0123456789abcdef"))
+	require.Nil(t, err)
+
+	sessions := session.NewManager(store).Middleware()
+	csrf := middleware.CSRF([]string{})
+	handler := func(next http.Handler) http.Handler {
+		return sessions(csrf(next))
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	res := testMiddleware(t, handler, req, nil)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Empty(t, res.Result().Cookies())
+}
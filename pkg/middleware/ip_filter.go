@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"sync"
+
+	"github.com/XDoubleU/essentia/internal/shared"
+	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+)
+
+// IPFilter allows or denies requests by the IP of their client, as returned
+// by [ClientIP], using lists of CIDRs or IPs. Its lists can be replaced
+// at runtime using [IPFilter.Update], for example after reloading config.
+type IPFilter struct {
+	mu    *sync.RWMutex
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// NewIPFilter creates a new [IPFilter], see [IPFilter.Update].
+func NewIPFilter(allow []string, deny []string) (*IPFilter, error) {
+	filter := &IPFilter{
+		mu:    &sync.RWMutex{},
+		allow: []netip.Prefix{},
+		deny:  []netip.Prefix{},
+	}
+
+	err := filter.Update(allow, deny)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// Update replaces the allow and deny lists of an [IPFilter]. An IP is
+// allowed when it isn't in the deny list and either the allow list is empty
+// or it's in the allow list. When a list is invalid, nothing is replaced.
+func (f *IPFilter) Update(allow []string, deny []string) error {
+	allowPrefixes, err := parsePrefixes(allow)
+	if err != nil {
+		return err
+	}
+
+	denyPrefixes, err := parsePrefixes(deny)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.allow = allowPrefixes
+	f.deny = denyPrefixes
+
+	return nil
+}
+
+// parsePrefixes parses CIDRs, where IPs are treated as CIDRs of one IP.
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			ip, ipErr := parseIP(value)
+			if ipErr != nil {
+				return nil, err
+			}
+
+			prefix = netip.PrefixFrom(ip, ip.BitLen())
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// Allowed reports whether an IP is allowed by an [IPFilter].
+// Invalid IPs are never allowed.
+func (f *IPFilter) Allowed(ip string) bool {
+	addr, err := parseIP(ip)
+	if err != nil {
+		return false
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if containsIP(f.deny, addr) {
+		return false
+	}
+
+	return len(f.allow) == 0 || containsIP(f.allow, addr)
+}
+
+// AllowListed reports whether an IP is in the allow list
+// and not in the deny list of an [IPFilter].
+func (f *IPFilter) AllowListed(ip string) bool {
+	addr, err := parseIP(ip)
+	if err != nil {
+		return false
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return !containsIP(f.deny, addr) && containsIP(f.allow, addr)
+}
+
+func containsIP(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Middleware is middleware used to answer requests of clients
+// which aren't allowed with [httptools.ForbiddenResponse].
+// [RealIP] should be used before it when behind proxies.
+func (f *IPFilter) Middleware() shared.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !f.Allowed(ClientIP(r)) {
+				httptools.ForbiddenResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package middleware_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func filterRequest(
+	t *testing.T,
+	filter *middleware.IPFilter,
+	remoteAddr string,
+) int {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/admin", nil)
+	req.RemoteAddr = remoteAddr
+
+	return testMiddleware(t, filter.Middleware(), req, nil).Code
+}
+
+func TestIPFilter(t *testing.T) {
+	filter, err := middleware.NewIPFilter(
+		[]string{"10.0.0.0/8", "2001:db8::/32"},
+		[]string{"10.0.0.1"},
+	)
+	require.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, filterRequest(t, filter, "10.1.2.3:80"))
+	assert.Equal(t, http.StatusOK, filterRequest(t, filter, "[2001:db8::1]:80"))
+	assert.Equal(t, http.StatusForbidden, filterRequest(t, filter, "10.0.0.1:80"))
+	assert.Equal(t, http.StatusForbidden, filterRequest(t, filter, "127.0.0.1:80"))
+	assert.Equal(t, http.StatusForbidden, filterRequest(t, filter, "invalid"))
+
+	assert.True(t, filter.AllowListed("10.1.2.3"))
+	assert.False(t, filter.AllowListed("10.0.0.1"))
+
+	err = filter.Update(nil, []string{"127.0.0.0/8"})
+	require.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, filterRequest(t, filter, "10.0.0.1:80"))
+	assert.Equal(t, http.StatusForbidden, filterRequest(t, filter, "127.0.0.1:80"))
+	assert.False(t, filter.AllowListed("10.0.0.1"))
+}
+
+func TestIPFilterInvalid(t *testing.T) {
+	_, err := middleware.NewIPFilter([]string{"invalid"}, nil)
+	assert.NotNil(t, err)
+
+	filter, err := middleware.NewIPFilter(nil, []string{"127.0.0.1"})
+	require.Nil(t, err)
+
+	err = filter.Update(nil, []string{"10.0.0.0/33"})
+	assert.NotNil(t, err)
+	assert.False(t, filter.Allowed("127.0.0.1"))
+}
@@ -0,0 +1,230 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/XDoubleU/essentia/internal/shared"
+	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+	"github.com/XDoubleU/essentia/pkg/metrics"
+)
+
+const (
+	// shortLatencySmoothing and longLatencySmoothing are the weights of
+	// new latencies in the recent and long-term average latency.
+	shortLatencySmoothing = 0.2
+	longLatencySmoothing  = 0.02
+	// latencyTolerance and minLatencyIncrease are how much slower recent
+	// requests can be than usual before the limit is decreased.
+	latencyTolerance   = 2
+	minLatencyIncrease = 10 * time.Millisecond
+	decreaseFactor     = 0.9
+	defaultRetryTime   = time.Second
+)
+
+// ConcurrencyLimit configures a [ConcurrencyLimiter]. At most Max requests
+// are handled at once, but when requests get slower than usual this limit
+// is lowered down to Min. Requests above the limit wait for at most
+// MaxQueueLatency and are rejected when they didn't get a turn by then.
+// Rejected requests are told to retry after RetryAfter, 1 second by default.
+type ConcurrencyLimit struct {
+	Min             int
+	Max             int
+	MaxQueueLatency time.Duration
+	RetryAfter      time.Duration
+}
+
+// ConcurrencyLimiter limits the amount of requests handled at once to shed
+// load when a server is overloaded. Its limit adapts to the latency of
+// requests: it's decreased when recent requests are a lot slower than
+// usual and increased again while requests are fast and the limit is reached.
+type ConcurrencyLimiter struct {
+	config       ConcurrencyLimit
+	mu           *sync.Mutex
+	limit        float64
+	inFlight     int
+	waiting      []chan struct{}
+	shortLatency float64
+	longLatency  float64
+	rejections   *metrics.Counter
+}
+
+// NewConcurrencyLimiter creates a new [ConcurrencyLimiter].
+func NewConcurrencyLimiter(config ConcurrencyLimit) *ConcurrencyLimiter {
+	config.Min = max(config.Min, 1)
+	config.Max = max(config.Max, config.Min)
+
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = defaultRetryTime
+	}
+
+	return &ConcurrencyLimiter{
+		config:       config,
+		mu:           &sync.Mutex{},
+		limit:        float64(config.Max),
+		inFlight:     0,
+		waiting:      []chan struct{}{},
+		shortLatency: 0,
+		longLatency:  0,
+		rejections:   nil,
+	}
+}
+
+// Limit returns the current limit of a [ConcurrencyLimiter].
+func (l *ConcurrencyLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(l.limit)
+}
+
+// InFlight returns the amount of requests being handled.
+func (l *ConcurrencyLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.inFlight
+}
+
+// RegisterMetrics exposes the limit, the amount of requests being handled
+// and the amount of rejected requests of a [ConcurrencyLimiter] in registry.
+// This should be done before the [ConcurrencyLimiter] is used.
+func (l *ConcurrencyLimiter) RegisterMetrics(registry *metrics.Registry) {
+	registry.GaugeFunc(
+		"concurrency_limit",
+		"Current limit of requests handled at once.",
+		nil,
+		func() []metrics.Sample {
+			return []metrics.Sample{{LabelValues: nil, Value: float64(l.Limit())}}
+		},
+	)
+	registry.GaugeFunc(
+		"concurrency_in_flight",
+		"Amount of requests being handled.",
+		nil,
+		func() []metrics.Sample {
+			return []metrics.Sample{{LabelValues: nil, Value: float64(l.InFlight())}}
+		},
+	)
+	l.rejections = registry.Counter(
+		"concurrency_rejections_total",
+		"Total amount of requests rejected by the concurrency limiter.",
+	)
+}
+
+// Middleware is middleware used to limit the amount of requests handled at
+// once. Rejected requests are answered with
+// [httptools.ServiceUnavailableResponse] and a Retry-After header.
+// Requests to exemptPaths, such as health checks, are never limited.
+func (l *ConcurrencyLimiter) Middleware(exemptPaths ...string) shared.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(exemptPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !l.acquire(r) {
+				if l.rejections != nil {
+					l.rejections.Inc()
+				}
+
+				w.Header().Set("Retry-After", seconds(l.config.RetryAfter))
+				httptools.ServiceUnavailableResponse(w, r)
+				return
+			}
+
+			start := time.Now()
+			defer func() {
+				l.release(time.Since(start))
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// acquire waits for a turn to handle a request and reports if it got one.
+func (l *ConcurrencyLimiter) acquire(r *http.Request) bool {
+	l.mu.Lock()
+	if l.inFlight < int(l.limit) {
+		l.inFlight++
+		l.mu.Unlock()
+		return true
+	}
+
+	if l.config.MaxQueueLatency <= 0 {
+		l.mu.Unlock()
+		return false
+	}
+
+	turn := make(chan struct{})
+	l.waiting = append(l.waiting, turn)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.config.MaxQueueLatency)
+	defer timer.Stop()
+
+	select {
+	case <-turn:
+		return true
+	case <-timer.C:
+	case <-r.Context().Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	index := slices.Index(l.waiting, turn)
+	if index == -1 {
+		// the turn was given while giving up
+		return true
+	}
+
+	l.waiting = slices.Delete(l.waiting, index, index+1)
+	return false
+}
+
+// release ends the turn of a request, adapts the limit
+// using its latency and gives turns to waiting requests.
+func (l *ConcurrencyLimiter) release(latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	wasLimited := l.inFlight >= int(l.limit)
+	l.inFlight--
+	l.adapt(latency.Seconds(), wasLimited)
+
+	for len(l.waiting) > 0 && l.inFlight < int(l.limit) {
+		close(l.waiting[0])
+		l.waiting = l.waiting[1:]
+		l.inFlight++
+	}
+}
+
+// adapt lowers the limit when recent requests are a lot slower than
+// usual and raises it when the limit was reached by fast requests.
+// The caller should hold the lock.
+func (l *ConcurrencyLimiter) adapt(latency float64, wasLimited bool) {
+	if l.longLatency == 0 {
+		l.shortLatency = latency
+		l.longLatency = latency
+		return
+	}
+
+	l.shortLatency += (latency - l.shortLatency) * shortLatencySmoothing
+	l.longLatency += (latency - l.longLatency) * longLatencySmoothing
+
+	increase := l.shortLatency - l.longLatency
+
+	switch {
+	case l.shortLatency > l.longLatency*latencyTolerance &&
+		increase > minLatencyIncrease.Seconds():
+		l.limit = math.Max(float64(l.config.Min), l.limit*decreaseFactor)
+	case wasLimited:
+		l.limit = math.Min(float64(l.config.Max), l.limit+1)
+	}
+}
@@ -0,0 +1,128 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/XDoubleU/essentia/pkg/metrics"
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func blockingHandler(release chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/block" {
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func shedRequest(handler http.Handler, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com"+path, nil)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	limiter := middleware.NewConcurrencyLimiter(middleware.ConcurrencyLimit{
+		Min:             1,
+		Max:             1,
+		MaxQueueLatency: 20 * time.Millisecond,
+		RetryAfter:      2 * time.Second,
+	})
+	registry := metrics.NewRegistry()
+	limiter.RegisterMetrics(registry)
+
+	release := make(chan struct{})
+	handler := limiter.Middleware("/health")(blockingHandler(release))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		shedRequest(handler, "/block")
+	}()
+
+	assert.Eventually(t, func() bool {
+		return limiter.InFlight() == 1
+	}, time.Second, time.Millisecond)
+
+	res := shedRequest(handler, "/foo")
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Equal(t, "2", res.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, shedRequest(handler, "/health").Code)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, http.StatusOK, shedRequest(handler, "/foo").Code)
+	assert.Equal(t, 0, limiter.InFlight())
+
+	output := scrapeMetrics(registry)
+	assert.Contains(t, output, "concurrency_limit 1\n")
+	assert.Contains(t, output, "concurrency_rejections_total 1\n")
+}
+
+func TestConcurrencyLimiterQueue(t *testing.T) {
+	limiter := middleware.NewConcurrencyLimiter(middleware.ConcurrencyLimit{
+		Min:             1,
+		Max:             1,
+		MaxQueueLatency: time.Second,
+		RetryAfter:      0,
+	})
+
+	release := make(chan struct{})
+	handler := limiter.Middleware()(blockingHandler(release))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		shedRequest(handler, "/block")
+	}()
+
+	assert.Eventually(t, func() bool {
+		return limiter.InFlight() == 1
+	}, time.Second, time.Millisecond)
+
+	time.AfterFunc(20*time.Millisecond, func() {
+		close(release)
+	})
+
+	assert.Equal(t, http.StatusOK, shedRequest(handler, "/foo").Code)
+	wg.Wait()
+}
+
+func TestConcurrencyLimiterAdapts(t *testing.T) {
+	limiter := middleware.NewConcurrencyLimiter(middleware.ConcurrencyLimit{
+		Min:             2,
+		Max:             10,
+		MaxQueueLatency: 0,
+		RetryAfter:      0,
+	})
+
+	delay := time.Duration(0)
+	handler := limiter.Middleware()(
+		http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+			time.Sleep(delay)
+		}),
+	)
+
+	for range 20 {
+		shedRequest(handler, "/foo")
+	}
+	assert.Equal(t, 10, limiter.Limit())
+
+	delay = 30 * time.Millisecond
+	for range 10 {
+		shedRequest(handler, "/foo")
+	}
+	assert.Less(t, limiter.Limit(), 10)
+	assert.GreaterOrEqual(t, limiter.Limit(), 2)
+}
@@ -2,36 +2,205 @@ package middleware
 
 import (
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/XDoubleU/essentia/internal/shared"
 	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
-	"github.com/XDoubleU/essentia/pkg/context"
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+	"github.com/XDoubleU/essentia/pkg/metrics"
 )
 
+// Router is used by [Logger], [Tracing], [RouteKey] and route timeouts to
+// find the pattern of the route of a request, for example a [http.ServeMux].
+type Router interface {
+	Handler(r *http.Request) (http.Handler, string)
+}
+
+// LoggerOption configures which requests [Logger] logs and how.
+type LoggerOption func(options *loggerOptions)
+
+type loggerOptions struct {
+	router        Router
+	excludedPaths []string
+	sampledPaths  []string
+	sampleRate    float64
+	slowThreshold time.Duration
+	requests      *metrics.Counter
+	durations     *metrics.Histogram
+}
+
+// WithRouter sets the [Router] used to log the pattern of
+// the route of a request. This is also used to match paths.
+func WithRouter(router Router) LoggerOption {
+	return func(options *loggerOptions) {
+		options.router = router
+	}
+}
+
+// WithExcludedPaths excludes requests to paths
+// or route patterns from logging, for example health checks.
+func WithExcludedPaths(paths ...string) LoggerOption {
+	return func(options *loggerOptions) {
+		options.excludedPaths = paths
+	}
+}
+
+// WithSampling only logs a fraction, being rate, of the successful
+// requests to paths or route patterns, for example high-volume routes.
+// Failed and slow requests are always logged.
+func WithSampling(rate float64, paths ...string) LoggerOption {
+	return func(options *loggerOptions) {
+		options.sampleRate = rate
+		options.sampledPaths = paths
+	}
+}
+
+// WithSlowThreshold logs requests taking longer than threshold
+// as warning, even when they are successful.
+func WithSlowThreshold(threshold time.Duration) LoggerOption {
+	return func(options *loggerOptions) {
+		options.slowThreshold = threshold
+	}
+}
+
+// WithMetrics records the amount of requests and their duration by
+// method, route and status in registry. Unlike logs, these are
+// also recorded for excluded and sampled requests.
+func WithMetrics(registry *metrics.Registry) LoggerOption {
+	return func(options *loggerOptions) {
+		options.requests = registry.Counter(
+			"http_requests_total",
+			"Total amount of processed HTTP requests.",
+			"method", "route", "status",
+		)
+		options.durations = registry.Histogram(
+			"http_request_duration_seconds",
+			"Duration of processed HTTP requests in seconds.",
+			nil,
+			"method", "route", "status",
+		)
+	}
+}
+
 // Logger is middleware used to add a logger to
 // the context and log every request and their duration.
-func Logger(logger *slog.Logger) shared.Middleware {
+// Requests resulting in a server error are logged as error,
+// client errors and slow requests as warning and others as info.
+// The IP of the client is resolved by [RealIP] and the ID of the request
+// is set by [RequestID] when these are used before Logger.
+func Logger(logger *slog.Logger, options ...LoggerOption) shared.Middleware {
+	//nolint:exhaustruct //other fields are optional
+	loggerOptions := loggerOptions{
+		excludedPaths: []string{},
+		sampledPaths:  []string{},
+		sampleRate:    1,
+	}
+
+	for _, option := range options {
+		option(&loggerOptions)
+	}
+
 	return func(next http.Handler) http.Handler {
-		return loggerHandler(logger, next)
+		return loggerHandler(logger, loggerOptions, next)
 	}
 }
 
-func loggerHandler(logger *slog.Logger, next http.Handler) http.Handler {
+func loggerHandler(
+	logger *slog.Logger,
+	options loggerOptions,
+	next http.Handler,
+) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		rw := httptools.NewResponseWriter(w)
 		t := time.Now()
 
-		r = r.WithContext(context.WithLogger(r.Context(), logger))
+		requestLogger := logger
+		if id := contexttools.RequestID(r.Context()); id != "" {
+			requestLogger = logger.With(slog.String("request_id", id))
+		}
+
+		r = r.WithContext(contexttools.WithLogger(r.Context(), requestLogger))
 
 		next.ServeHTTP(rw, r)
 
-		logger.Info(
+		duration := time.Since(t)
+		route := options.route(r)
+
+		options.record(r.Method, route, rw.Status(), duration)
+
+		if matchesPath(options.excludedPaths, r, route) {
+			return
+		}
+
+		level := options.level(rw.Status(), duration)
+		if level == slog.LevelInfo &&
+			matchesPath(options.sampledPaths, r, route) &&
+			//nolint:gosec //sampling doesn't need a secure random number
+			rand.Float64() >= options.sampleRate {
+			return
+		}
+
+		requestLogger.LogAttrs(
+			r.Context(),
+			level,
 			"processed request",
 			slog.Int("status", rw.Status()),
+			slog.String("method", r.Method),
+			slog.String("route", route),
 			slog.String("endpoint", r.RequestURI),
-			slog.Duration("duration", time.Since(t)),
+			slog.String("client_ip", ClientIP(r)),
+			slog.String("user_agent", r.UserAgent()),
+			slog.Int64("bytes", rw.BytesWritten()),
+			slog.Duration("duration", duration),
 		)
 	})
 }
+
+func (options loggerOptions) route(r *http.Request) string {
+	if options.router == nil {
+		return ""
+	}
+
+	_, pattern := options.router.Handler(r)
+	return pattern
+}
+
+func (options loggerOptions) record(
+	method string,
+	route string,
+	status int,
+	duration time.Duration,
+) {
+	if options.requests == nil {
+		return
+	}
+
+	statusLabel := strconv.Itoa(status)
+	options.requests.Inc(method, route, statusLabel)
+	options.durations.Observe(duration.Seconds(), method, route, statusLabel)
+}
+
+func matchesPath(paths []string, r *http.Request, route string) bool {
+	return slices.Contains(paths, r.URL.Path) ||
+		(route != "" && slices.Contains(paths, route))
+}
+
+func (options loggerOptions) level(
+	status int,
+	duration time.Duration,
+) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	case options.slowThreshold > 0 && duration > options.slowThreshold:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
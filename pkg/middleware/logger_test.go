@@ -0,0 +1,164 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/XDoubleU/essentia/internal/mocks"
+	"github.com/XDoubleU/essentia/pkg/context"
+	"github.com/XDoubleU/essentia/pkg/metrics"
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func logRequest(
+	t *testing.T,
+	path string,
+	status int,
+	delay time.Duration,
+	options ...middleware.LoggerOption,
+) string {
+	t.Helper()
+
+	mockedLogger := mocks.MockedLogger{}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com"+path, nil)
+	req.RemoteAddr = "127.0.0.1:80"
+	req.Header.Set("User-Agent", "test-agent")
+	req.RequestURI = path
+	req = req.WithContext(context.WithRequestID(req.Context(), "abc"))
+
+	testMiddleware(
+		t,
+		middleware.Logger(mockedLogger.Logger(), options...),
+		req,
+		func(w http.ResponseWriter, _ *http.Request) {
+			time.Sleep(delay)
+			w.WriteHeader(status)
+		},
+	)
+
+	return mockedLogger.CapturedLogs()
+}
+
+func scrapeMetrics(registry *metrics.Registry) string {
+	rr := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rr.Body.String()
+}
+
+func TestLoggerAttributes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{id}", func(_ http.ResponseWriter, _ *http.Request) {})
+
+	logs := logRequest(
+		t,
+		"/users/1",
+		http.StatusOK,
+		0,
+		middleware.WithRouter(mux),
+	)
+
+	for _, expected := range []string{
+		"level=INFO",
+		"request_id=abc",
+		"status=200",
+		"method=GET",
+		"route=/users/{id}",
+		"endpoint=/users/1",
+		"client_ip=127.0.0.1",
+		"user_agent=test-agent",
+		"bytes=3",
+	} {
+		assert.Contains(t, logs, expected)
+	}
+}
+
+func TestLoggerLevels(t *testing.T) {
+	assert.Contains(
+		t,
+		logRequest(t, "/foo", http.StatusInternalServerError, 0),
+		"level=ERROR",
+	)
+	assert.Contains(
+		t,
+		logRequest(t, "/foo", http.StatusNotFound, 0),
+		"level=WARN",
+	)
+	assert.Contains(
+		t,
+		logRequest(
+			t,
+			"/foo",
+			http.StatusOK,
+			20*time.Millisecond,
+			middleware.WithSlowThreshold(10*time.Millisecond),
+		),
+		"level=WARN",
+	)
+}
+
+func TestLoggerExcludedPaths(t *testing.T) {
+	option := middleware.WithExcludedPaths("/health")
+
+	assert.Empty(t, logRequest(t, "/health", http.StatusOK, 0, option))
+	assert.NotEmpty(t, logRequest(t, "/foo", http.StatusOK, 0, option))
+}
+
+func TestLoggerSampling(t *testing.T) {
+	option := middleware.WithSampling(0, "/foo")
+
+	assert.Empty(t, logRequest(t, "/foo", http.StatusOK, 0, option))
+	assert.NotEmpty(t, logRequest(t, "/foo", http.StatusBadRequest, 0, option))
+	assert.NotEmpty(t, logRequest(t, "/bar", http.StatusOK, 0, option))
+
+	option = middleware.WithSampling(1, "/foo")
+	assert.Equal(
+		t,
+		1,
+		strings.Count(logRequest(t, "/foo", http.StatusOK, 0, option), "\n"),
+	)
+}
+
+func TestLoggerMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{id}", func(_ http.ResponseWriter, _ *http.Request) {})
+
+	registry := metrics.NewRegistry()
+	option := middleware.WithMetrics(registry)
+	routerOption := middleware.WithRouter(mux)
+	excludeOption := middleware.WithExcludedPaths("/users/{id}")
+
+	logRequest(t, "/users/1", http.StatusOK, 0, routerOption, option)
+	logRequest(
+		t,
+		"/users/2",
+		http.StatusOK,
+		0,
+		routerOption,
+		option,
+		excludeOption,
+	)
+	logRequest(t, "/users/3", http.StatusNotFound, 0, routerOption, option)
+
+	output := scrapeMetrics(registry)
+	assert.Contains(
+		t,
+		output,
+		`http_requests_total{method="GET",route="/users/{id}",status="200"} 2`,
+	)
+	assert.Contains(
+		t,
+		output,
+		`http_requests_total{method="GET",route="/users/{id}",status="404"} 1`,
+	)
+	assert.Contains(
+		t,
+		output,
+		`http_request_duration_seconds_count`+
+			`{method="GET",route="/users/{id}",status="200"} 2`,
+	)
+}
@@ -1,12 +1,12 @@
-// Package middleware provides configurable middleware and predefined lists,
-// such as [Minimal], [Default] and [DefaultWithSentry].
+// Package middleware provides configurable middleware, a builder for
+// chains of middleware, being [Stack], and presets of it, such as
+// [Minimal], [Default] and [DefaultWithSentry].
 package middleware
 
 import (
 	"log/slog"
 	"time"
 
-	sentrytools "github.com/XDoubleU/essentia/pkg/sentry"
 	"github.com/getsentry/sentry-go"
 	"github.com/goddtriffin/helmet"
 	"github.com/justinas/alice"
@@ -17,13 +17,13 @@ import (
 //   - [Logger]
 //   - [Recover]
 func Minimal(logger *slog.Logger) []alice.Constructor {
-	return []alice.Constructor{
-		Logger(logger),
-		Recover(logger),
-	}
+	//nolint:errcheck //can't fail without options
+	handlers, _ := Stack(logger)
+	return handlers
 }
 
-// Default provides a predefined chain of useful middleware.
+// Default provides a predefined chain of useful middleware,
+// which can be adjusted using [StackOption]s.
 // Being:
 //   - All middleware from [Minimal]
 //   - [helmet.Helmet]
@@ -32,47 +32,42 @@ func Minimal(logger *slog.Logger) []alice.Constructor {
 func Default(
 	logger *slog.Logger,
 	allowedOrigins []string,
+	options ...StackOption,
 ) ([]alice.Constructor, error) {
-	return defaultBase(logger, allowedOrigins, nil, nil)
+	return Stack(logger, append(defaultOptions(allowedOrigins, false), options...)...)
 }
 
-// DefaultWithSentry provides a predefined chain of useful middleware.
+// DefaultWithSentry provides a predefined chain of useful middleware,
+// which can be adjusted using [StackOption]s.
 // Being:
 //   - All middleware from [Default]
-//   - [sentrytools.Middleware]
+//   - [sentrytools.Middleware], placed before [Recover]
+//     so panics are captured with their request
 func DefaultWithSentry(
 	logger *slog.Logger,
 	allowedOrigins []string,
 	env string,
 	sentryClientOptions sentry.ClientOptions,
+	options ...StackOption,
 ) ([]alice.Constructor, error) {
-	return defaultBase(logger, allowedOrigins, &env, &sentryClientOptions)
+	defaults := append(
+		defaultOptions(allowedOrigins, true),
+		WithSentry(env, sentryClientOptions),
+	)
+	return Stack(logger, append(defaults, options...)...)
 }
 
-func defaultBase(
-	logger *slog.Logger,
-	allowedOrigins []string,
-	env *string,
-	sentryClientOptions *sentry.ClientOptions,
-) ([]alice.Constructor, error) {
-	useSentry := env != nil && sentryClientOptions != nil
-
-	helmet := helmet.Default()
-
-	handlers := Minimal(logger)
-	handlers = append(handlers, helmet.Secure)
-	handlers = append(handlers, CORS(allowedOrigins, true))
-	//nolint:mnd//no magic number
-	handlers = append(handlers, RateLimit(10, 30, time.Minute, 3*time.Minute))
+func defaultOptions(allowedOrigins []string, useSentry bool) []StackOption {
+	//nolint:mnd //no magic number
+	rateLimiter := NewRateLimiter(
+		NewMemoryRateLimitStore(time.Minute, 3*time.Minute),
+		IPKey,
+	)
 
-	if useSentry {
-		sentryMiddleware, err := sentrytools.Middleware(*env, *sentryClientOptions)
-		if err != nil {
-			return nil, err
-		}
-
-		handlers = append(handlers, sentryMiddleware)
+	return []StackOption{
+		WithHelmet(helmet.Default()),
+		WithCORS(DefaultCORSOptions(allowedOrigins, useSentry)),
+		//nolint:mnd //no magic number
+		WithRateLimit(rateLimiter, Limit{Rate: 10, Burst: 30}),
 	}
-
-	return handlers, nil
 }
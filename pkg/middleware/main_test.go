@@ -78,8 +78,13 @@ func testMiddleware(
 func TestCORS(t *testing.T) {
 	allowedOrigins := []string{"http://example.com"}
 
-	sentryHeaders := []string{"content-type", "baggage", "sentry-trace"}
-	noSentryHeaders := []string{"content-type"}
+	sentryHeaders := []string{
+		"content-type",
+		"traceparent",
+		"baggage",
+		"sentry-trace",
+	}
+	noSentryHeaders := []string{"content-type", "traceparent"}
 
 	corsSentry := middleware.CORS(allowedOrigins, true)
 	corsNoSentry := middleware.CORS(allowedOrigins, false)
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/XDoubleU/essentia/internal/shared"
+	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+)
+
+// Maintenance is used to put an application into maintenance mode,
+// which can be switched on and off at runtime.
+type Maintenance struct {
+	mu          *sync.RWMutex
+	enabled     bool
+	retryAfter  time.Duration
+	allowed     *IPFilter
+	exemptPaths []string
+}
+
+// NewMaintenance creates a new [Maintenance], which is disabled.
+// During maintenance, requests to exemptPaths, for example health checks,
+// and of clients in the allow list of allowed, which can be nil,
+// are still handled.
+func NewMaintenance(allowed *IPFilter, exemptPaths ...string) *Maintenance {
+	return &Maintenance{
+		mu:          &sync.RWMutex{},
+		enabled:     false,
+		retryAfter:  0,
+		allowed:     allowed,
+		exemptPaths: exemptPaths,
+	}
+}
+
+// Enable enables maintenance mode. Rejected requests are
+// told to retry after retryAfter when it's positive.
+func (m *Maintenance) Enable(retryAfter time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.enabled = true
+	m.retryAfter = retryAfter
+}
+
+// Disable disables maintenance mode.
+func (m *Maintenance) Disable() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.enabled = false
+}
+
+// Enabled reports whether maintenance mode is enabled.
+func (m *Maintenance) Enabled() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.enabled
+}
+
+// Middleware is middleware used to answer requests during maintenance with
+// [httptools.ServiceUnavailableResponse] and a Retry-After header.
+// [RealIP] should be used before it when behind proxies.
+func (m *Maintenance) Middleware() shared.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.mu.RLock()
+			enabled, retryAfter := m.enabled, m.retryAfter
+			m.mu.RUnlock()
+
+			if !enabled ||
+				slices.Contains(m.exemptPaths, r.URL.Path) ||
+				(m.allowed != nil && m.allowed.AllowListed(ClientIP(r))) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if retryAfter > 0 {
+				w.Header().Set("Retry-After", seconds(retryAfter))
+			}
+			httptools.ServiceUnavailableResponse(w, r)
+		})
+	}
+}
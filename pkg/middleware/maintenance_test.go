@@ -0,0 +1,75 @@
+package middleware_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+	errortools "github.com/XDoubleU/essentia/pkg/errors"
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenance(t *testing.T) {
+	filter, err := middleware.NewIPFilter([]string{"10.0.0.0/8"}, nil)
+	require.Nil(t, err)
+
+	maintenance := middleware.NewMaintenance(filter, "/health")
+
+	request := func(path string, remoteAddr string) *http.Request {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com"+path, nil)
+		req.RemoteAddr = remoteAddr
+		return req
+	}
+
+	res := testMiddleware(
+		t,
+		maintenance.Middleware(),
+		request("/foo", "127.0.0.1:80"),
+		nil,
+	)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	maintenance.Enable(90 * time.Second)
+	assert.True(t, maintenance.Enabled())
+
+	res = testMiddleware(
+		t,
+		maintenance.Middleware(),
+		request("/foo", "127.0.0.1:80"),
+		nil,
+	)
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Equal(t, "90", res.Header().Get("Retry-After"))
+
+	var errorDto errortools.ErrorDto
+	require.Nil(t, httptools.ReadJSON(res.Body, &errorDto))
+	assert.Equal(t, errortools.MessageServiceUnavailable, errorDto.Message)
+
+	res = testMiddleware(
+		t,
+		maintenance.Middleware(),
+		request("/health", "127.0.0.1:80"),
+		nil,
+	)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	res = testMiddleware(
+		t,
+		maintenance.Middleware(),
+		request("/foo", "10.0.0.1:80"),
+		nil,
+	)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	maintenance.Disable()
+	res = testMiddleware(
+		t,
+		maintenance.Middleware(),
+		request("/foo", "127.0.0.1:80"),
+		nil,
+	)
+	assert.Equal(t, http.StatusOK, res.Code)
+}
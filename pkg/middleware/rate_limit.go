@@ -1,98 +1,257 @@
 package middleware
 
 import (
-	"net"
+	"context"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/XDoubleU/essentia/internal/shared"
 	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+	"github.com/XDoubleU/essentia/pkg/jwt"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/metrics"
+	"github.com/XDoubleU/essentia/pkg/stores"
 	"golang.org/x/time/rate"
 )
 
-var cleanerActive bool //nolint: gochecknoglobals //need this
+// KeyFunc identifies the client of a request for a [RateLimiter].
+type KeyFunc = func(r *http.Request) (string, error)
 
-type client struct {
-	limiter  *rate.Limiter
-	lastSeen time.Time
+// Limit allows clients Burst requests at once,
+// after which tokens are refilled at Rate per second.
+type Limit = stores.Limit
+
+// TokenBucket is the state of a client stored by a [RateLimitStore].
+// A zero TokenBucket is full.
+type TokenBucket = stores.TokenBucket
+
+// RateLimitResult is the outcome of taking a token of a [TokenBucket].
+type RateLimitResult = stores.RateLimitResult
+
+// RateLimitStore stores the [TokenBucket]s of a [RateLimiter].
+// Take should atomically take a token of the bucket of a key,
+// implementations can use [stores.TakeToken] for this.
+type RateLimitStore = stores.RateLimitStore
+
+// RateLimiter is used to rate limit requests of clients identified
+// by a [KeyFunc], with their state stored in a [RateLimitStore].
+type RateLimiter struct {
+	store      RateLimitStore
+	key        KeyFunc
+	rejections *metrics.Counter
+}
+
+// NewRateLimiter creates a new [RateLimiter].
+func NewRateLimiter(store RateLimitStore, key KeyFunc) *RateLimiter {
+	return &RateLimiter{
+		store:      store,
+		key:        key,
+		rejections: nil,
+	}
+}
+
+// RegisterMetrics records the amount of rejected requests per group in
+// registry. This should be done before the [RateLimiter] is used.
+func (l *RateLimiter) RegisterMetrics(registry *metrics.Registry) {
+	l.rejections = registry.Counter(
+		"rate_limit_rejections_total",
+		"Total amount of requests rejected by the rate limiter.",
+		"group",
+	)
 }
 
 // RateLimit is middleware used to rate limit requests by clients identified by IP.
+// Clients which haven't been seen for removeAfter are removed every cleanupTimer.
 func RateLimit(
 	rps rate.Limit,
 	bucketSize int,
 	cleanupTimer time.Duration,
 	removeAfter time.Duration,
 ) shared.Middleware {
-	var (
-		mu      sync.RWMutex
-		clients = make(map[string]*client)
-	)
-
-	if !cleanerActive {
-		cleanerActive = true
-		go func() {
-			for {
-				time.Sleep(cleanupTimer)
+	store := NewMemoryRateLimitStore(cleanupTimer, removeAfter)
+	return NewRateLimiter(store, IPKey).Limit("", Limit{
+		Rate:  rps,
+		Burst: bucketSize,
+	})
+}
 
-				mu.RLock()
+// Limit is middleware used to rate limit requests using the provided [Limit].
+// Clients have a separate [TokenBucket] per group, so different route groups
+// can have different limits. The RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers are set on every response and Retry-After
+// when the limit is exceeded. When the store fails, requests are allowed.
+func (l *RateLimiter) Limit(group string, limit Limit) shared.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, err := l.key(r)
+			if err != nil {
+				httptools.ServerErrorResponse(w, r, err)
+				return
+			}
 
-				for ip, client := range clients {
-					if time.Since(client.lastSeen) > removeAfter {
-						mu.RUnlock()
-						mu.Lock()
+			result, err := l.store.Take(r.Context(), group+":"+key, limit)
+			if err != nil {
+				contexttools.Logger(r.Context()).ErrorContext(
+					r.Context(),
+					"failed to rate limit request",
+					logging.ErrAttr(err),
+				)
+				next.ServeHTTP(w, r)
+				return
+			}
 
-						delete(clients, ip)
+			setRateLimitHeaders(w, result)
 
-						mu.Unlock()
-						mu.RLock()
-					}
+			if !result.Allowed {
+				if l.rejections != nil {
+					l.rejections.Inc(group)
 				}
 
-				mu.RUnlock()
+				w.Header().Set("Retry-After", seconds(result.RetryAfter))
+				httptools.RateLimitExceededResponse(w, r)
+				return
 			}
-		}()
+
+			next.ServeHTTP(w, r)
+		})
 	}
+}
 
-	return func(next http.Handler) http.Handler {
-		return rateLimit(&mu, clients, rps, bucketSize, next)
+func setRateLimitHeaders(w http.ResponseWriter, result RateLimitResult) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", seconds(result.Reset))
+}
+
+// seconds formats a duration as seconds, rounded up.
+func seconds(duration time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(duration.Seconds())))
+}
+
+// IPKey identifies clients by their IP, as returned by [ClientIP].
+func IPKey(r *http.Request) (string, error) {
+	ip := ClientIP(r)
+	if ip == "" {
+		return "", fmt.Errorf("invalid remote address '%s'", r.RemoteAddr)
 	}
+
+	return ip, nil
 }
 
-func rateLimit(
-	mu *sync.RWMutex,
-	clients map[string]*client,
-	rps rate.Limit,
-	bucketSize int,
-	next http.Handler,
-) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip, _, err := net.SplitHostPort(r.RemoteAddr)
-		if err != nil {
-			httptools.ServerErrorResponse(w, r, err)
-			return
+// HeaderKey identifies clients by the value of a header,
+// for example an API key. When the header is missing,
+// the fallback [KeyFunc] is used instead.
+func HeaderKey(header string, fallback KeyFunc) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		value := r.Header.Get(header)
+		if value == "" {
+			return fallback(r)
 		}
 
-		mu.Lock()
+		return header + ":" + value, nil
+	}
+}
 
-		if _, found := clients[ip]; !found {
-			//nolint:exhaustruct //lastSeen is set later
-			clients[ip] = &client{
-				limiter: rate.NewLimiter(rps, bucketSize),
-			}
+// UserKey identifies clients by the subject of the claims stored
+// in the context by [Authenticate], so the [RateLimiter] should be used
+// after it. For unauthenticated requests the fallback [KeyFunc] is used.
+func UserKey(fallback KeyFunc) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		claims := contexttools.Claims[jwt.ClaimsType](r.Context())
+		if claims == nil || (*claims).Registered().Subject == "" {
+			return fallback(r)
 		}
 
-		clients[ip].lastSeen = time.Now()
+		return "user:" + (*claims).Registered().Subject, nil
+	}
+}
 
-		if !clients[ip].limiter.Allow() {
-			mu.Unlock()
-			httptools.RateLimitExceededResponse(w, r)
-			return
+// RouteKey identifies clients by the [KeyFunc] key per route,
+// so clients have a separate [TokenBucket] for every pattern found
+// by router, for example a [http.ServeMux].
+func RouteKey(router Router, key KeyFunc) KeyFunc {
+	return func(r *http.Request) (string, error) {
+		client, err := key(r)
+		if err != nil {
+			return "", err
 		}
 
-		mu.Unlock()
+		_, route := router.Handler(r)
+		return route + ":" + client, nil
+	}
+}
+
+// MemoryRateLimitStore is a [RateLimitStore] storing
+// the [TokenBucket]s of clients in memory.
+type MemoryRateLimitStore struct {
+	mu              *sync.Mutex
+	buckets         map[string]*TokenBucket
+	cleanupInterval time.Duration
+	removeAfter     time.Duration
+	lastCleanup     time.Time
+}
 
-		next.ServeHTTP(w, r)
-	})
+// NewMemoryRateLimitStore creates a new [MemoryRateLimitStore].
+// Every cleanupInterval, buckets which weren't updated
+// for removeAfter are removed while taking a token.
+func NewMemoryRateLimitStore(
+	cleanupInterval time.Duration,
+	removeAfter time.Duration,
+) *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		mu:              &sync.Mutex{},
+		buckets:         make(map[string]*TokenBucket),
+		cleanupInterval: cleanupInterval,
+		removeAfter:     removeAfter,
+		lastCleanup:     time.Now(),
+	}
+}
+
+// Take takes a token of the bucket of a key.
+func (s *MemoryRateLimitStore) Take(
+	_ context.Context,
+	key string,
+	limit Limit,
+) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.cleanup(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		//nolint:exhaustruct //a zero bucket is full
+		bucket = &TokenBucket{}
+		s.buckets[key] = bucket
+	}
+
+	return stores.TakeToken(bucket, limit, now), nil
+}
+
+// cleanup removes stale buckets, the caller should hold the lock.
+func (s *MemoryRateLimitStore) cleanup(now time.Time) {
+	if now.Sub(s.lastCleanup) < s.cleanupInterval {
+		return
+	}
+	s.lastCleanup = now
+
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.Updated) > s.removeAfter {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Len returns the amount of clients stored in a [MemoryRateLimitStore].
+func (s *MemoryRateLimitStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.buckets)
 }
@@ -0,0 +1,187 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+	"github.com/XDoubleU/essentia/pkg/jwt"
+	"github.com/XDoubleU/essentia/pkg/metrics"
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func rateLimitRequest(
+	t *testing.T,
+	handler func(next http.Handler) http.Handler,
+	apiKey string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	req.RemoteAddr = "127.0.0.1:80"
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+
+	return testMiddleware(t, handler, req, nil)
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	limiter := middleware.NewRateLimiter(
+		middleware.NewMemoryRateLimitStore(time.Minute, time.Minute),
+		middleware.IPKey,
+	)
+	handler := limiter.Limit("api", middleware.Limit{Rate: 1, Burst: 2})
+
+	res := rateLimitRequest(t, handler, "")
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Equal(t, "2", res.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", res.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", res.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, res.Header().Get("Retry-After"))
+
+	res = rateLimitRequest(t, handler, "")
+	assert.Equal(t, http.StatusOK, res.Result().StatusCode)
+	assert.Equal(t, "0", res.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", res.Header().Get("RateLimit-Reset"))
+
+	res = rateLimitRequest(t, handler, "")
+	assert.Equal(t, http.StatusTooManyRequests, res.Result().StatusCode)
+	assert.Equal(t, "0", res.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", res.Header().Get("Retry-After"))
+}
+
+func TestRateLimitGroups(t *testing.T) {
+	limiter := middleware.NewRateLimiter(
+		middleware.NewMemoryRateLimitStore(time.Minute, time.Minute),
+		middleware.IPKey,
+	)
+	strict := limiter.Limit("strict", middleware.Limit{Rate: 1, Burst: 1})
+	relaxed := limiter.Limit("relaxed", middleware.Limit{Rate: 10, Burst: 10})
+
+	assert.Equal(t, http.StatusOK, rateLimitRequest(t, strict, "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, rateLimitRequest(t, strict, "").Code)
+
+	res := rateLimitRequest(t, relaxed, "")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "9", res.Header().Get("RateLimit-Remaining"))
+}
+
+func TestRateLimitMetrics(t *testing.T) {
+	limiter := middleware.NewRateLimiter(
+		middleware.NewMemoryRateLimitStore(time.Minute, time.Minute),
+		middleware.IPKey,
+	)
+	registry := metrics.NewRegistry()
+	limiter.RegisterMetrics(registry)
+	handler := limiter.Limit("strict", middleware.Limit{Rate: 1, Burst: 1})
+
+	for range 3 {
+		rateLimitRequest(t, handler, "")
+	}
+
+	assert.Contains(
+		t,
+		scrapeMetrics(registry),
+		`rate_limit_rejections_total{group="strict"} 2`,
+	)
+}
+
+func TestRateLimitHeaderKey(t *testing.T) {
+	limiter := middleware.NewRateLimiter(
+		middleware.NewMemoryRateLimitStore(time.Minute, time.Minute),
+		middleware.HeaderKey("X-API-Key", middleware.IPKey),
+	)
+	handler := limiter.Limit("", middleware.Limit{Rate: 1, Burst: 1})
+
+	assert.Equal(t, http.StatusOK, rateLimitRequest(t, handler, "a").Code)
+	assert.Equal(t, http.StatusOK, rateLimitRequest(t, handler, "b").Code)
+	assert.Equal(t, http.StatusOK, rateLimitRequest(t, handler, "").Code)
+
+	assert.Equal(
+		t,
+		http.StatusTooManyRequests,
+		rateLimitRequest(t, handler, "a").Code,
+	)
+	assert.Equal(
+		t,
+		http.StatusTooManyRequests,
+		rateLimitRequest(t, handler, "").Code,
+	)
+}
+
+func TestMemoryRateLimitStoreCleanup(t *testing.T) {
+	store := middleware.NewMemoryRateLimitStore(
+		10*time.Millisecond,
+		10*time.Millisecond,
+	)
+	limiter := middleware.NewRateLimiter(
+		store,
+		middleware.HeaderKey("X-API-Key", middleware.IPKey),
+	)
+	handler := limiter.Limit("", middleware.Limit{Rate: 1, Burst: 1})
+
+	rateLimitRequest(t, handler, "a")
+	rateLimitRequest(t, handler, "b")
+	assert.Equal(t, 2, store.Len())
+
+	time.Sleep(50 * time.Millisecond)
+
+	rateLimitRequest(t, handler, "c")
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestRateLimitUserKey(t *testing.T) {
+	limiter := middleware.NewRateLimiter(
+		middleware.NewMemoryRateLimitStore(time.Minute, time.Minute),
+		middleware.UserKey(middleware.IPKey),
+	)
+	handler := limiter.Limit("", middleware.Limit{Rate: 1, Burst: 1})
+
+	request := func(subject string) int {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+		req.RemoteAddr = "127.0.0.1:80"
+		if subject != "" {
+			//nolint:exhaustruct //other claims are optional
+			claims := jwt.Claims{Subject: subject}
+			req = req.WithContext(contexttools.WithClaims(req.Context(), claims))
+		}
+
+		return testMiddleware(t, handler, req, nil).Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("a"))
+	assert.Equal(t, http.StatusOK, request("b"))
+	assert.Equal(t, http.StatusOK, request(""))
+
+	assert.Equal(t, http.StatusTooManyRequests, request("a"))
+	assert.Equal(t, http.StatusTooManyRequests, request(""))
+}
+
+func TestRateLimitRouteKey(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{id}", func(_ http.ResponseWriter, _ *http.Request) {})
+	mux.HandleFunc("/posts", func(_ http.ResponseWriter, _ *http.Request) {})
+
+	limiter := middleware.NewRateLimiter(
+		middleware.NewMemoryRateLimitStore(time.Minute, time.Minute),
+		middleware.RouteKey(mux, middleware.IPKey),
+	)
+	handler := limiter.Limit("", middleware.Limit{Rate: 1, Burst: 1})
+
+	request := func(path string) int {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com"+path, nil)
+		req.RemoteAddr = "127.0.0.1:80"
+
+		return testMiddleware(t, handler, req, nil).Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("/users/1"))
+	assert.Equal(t, http.StatusOK, request("/posts"))
+
+	assert.Equal(t, http.StatusTooManyRequests, request("/users/2"))
+	assert.Equal(t, http.StatusTooManyRequests, request("/posts"))
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/XDoubleU/essentia/internal/shared"
+	"github.com/XDoubleU/essentia/pkg/context"
+)
+
+// RealIPOption configures [RealIP].
+type RealIPOption func(options *ipResolver)
+
+// WithIPHeader sets the header which is used to resolve the IP of the client,
+// being X-Forwarded-For by default. This should be the header set by
+// the trusted proxies, for example Forwarded or X-Real-IP,
+// as other headers are passed on unchanged and can be set by clients.
+func WithIPHeader(header string) RealIPOption {
+	return func(options *ipResolver) {
+		options.header = http.CanonicalHeaderKey(header)
+	}
+}
+
+// RealIP is middleware used to resolve the IP of the client of a request
+// when running behind proxies, with trustedProxies being their CIDRs.
+// The IP is resolved from the header set by the proxies, see [WithIPHeader],
+// which is only used when the request was sent by a trusted proxy.
+// This header is read from right to left, skipping trusted proxies,
+// so values added by a client can't spoof its IP.
+// The result is stored in the context and available through [ClientIP],
+// so RealIP should be used before [Logger] and [RateLimit].
+func RealIP(
+	trustedProxies []string,
+	options ...RealIPOption,
+) (shared.Middleware, error) {
+	prefixes := make([]netip.Prefix, 0, len(trustedProxies))
+	for _, proxy := range trustedProxies {
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, err
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	resolver := ipResolver{
+		trustedProxies: prefixes,
+		header:         "X-Forwarded-For",
+	}
+
+	for _, option := range options {
+		option(&resolver)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := resolver.resolve(r)
+			if ip != "" {
+				r = r.WithContext(context.WithClientIP(r.Context(), ip))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// ClientIP returns the IP of the client of a request as resolved
+// by [RealIP], or the IP of the remote address when it wasn't resolved.
+func ClientIP(r *http.Request) string {
+	if ip := context.ClientIP(r.Context()); ip != "" {
+		return ip
+	}
+
+	ip, err := parseIP(r.RemoteAddr)
+	if err != nil {
+		return ""
+	}
+
+	return ip.String()
+}
+
+type ipResolver struct {
+	trustedProxies []netip.Prefix
+	header         string
+}
+
+func (resolver ipResolver) resolve(r *http.Request) string {
+	remote, err := parseIP(r.RemoteAddr)
+	if err != nil {
+		return ""
+	}
+
+	if !resolver.isTrusted(remote) {
+		return remote.String()
+	}
+
+	var hops []string
+	if resolver.header == "Forwarded" {
+		hops = forwardedHops(r.Header.Values(resolver.header))
+	} else {
+		hops = splitHeaderValues(r.Header.Values(resolver.header))
+	}
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, parseErr := parseIP(hops[i])
+		if parseErr != nil {
+			break
+		}
+
+		client = ip
+		if !resolver.isTrusted(ip) {
+			break
+		}
+	}
+
+	return client.String()
+}
+
+func (resolver ipResolver) isTrusted(ip netip.Addr) bool {
+	for _, prefix := range resolver.trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseIP parses an IP which optionally has a port
+// and brackets, as used in RemoteAddr and Forwarded.
+func parseIP(value string) (netip.Addr, error) {
+	value = strings.TrimSpace(value)
+
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+
+	ip, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	return ip.Unmap().WithZone(""), nil
+}
+
+func splitHeaderValues(values []string) []string {
+	result := []string{}
+	for _, value := range values {
+		result = append(result, strings.Split(value, ",")...)
+	}
+
+	return result
+}
+
+// forwardedHops returns the for parameters of Forwarded headers,
+// with hops without a for parameter being kept as invalid IP.
+func forwardedHops(values []string) []string {
+	result := []string{}
+	for _, element := range splitHeaderValues(values) {
+		hop := ""
+		for _, pair := range strings.Split(element, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+			if strings.EqualFold(key, "for") {
+				hop = strings.Trim(value, `"`)
+			}
+		}
+
+		result = append(result, hop)
+	}
+
+	return result
+}
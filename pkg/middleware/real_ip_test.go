@@ -0,0 +1,158 @@
+package middleware_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resolveIP(
+	t *testing.T,
+	header string,
+	remoteAddr string,
+	headers map[string]string,
+) string {
+	t.Helper()
+
+	realIP, err := middleware.RealIP(
+		[]string{"10.0.0.0/8", "fd00::/8"},
+		middleware.WithIPHeader(header),
+	)
+	require.Nil(t, err)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	req.RemoteAddr = remoteAddr
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	var ip string
+	testMiddleware(t, realIP, req, func(_ http.ResponseWriter, r *http.Request) {
+		ip = middleware.ClientIP(r)
+	})
+
+	return ip
+}
+
+func TestRealIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{
+			name:       "untrusted remote",
+			header:     "X-Forwarded-For",
+			remoteAddr: "1.1.1.1:80",
+			headers:    map[string]string{"X-Forwarded-For": "2.2.2.2"},
+			expected:   "1.1.1.1",
+		},
+		{
+			name:       "no headers",
+			header:     "X-Forwarded-For",
+			remoteAddr: "10.0.0.1:80",
+			headers:    map[string]string{},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "x-forwarded-for",
+			header:     "X-Forwarded-For",
+			remoteAddr: "10.0.0.1:80",
+			headers: map[string]string{
+				"X-Forwarded-For": "3.3.3.3, 2.2.2.2, 10.0.0.2",
+			},
+			expected: "2.2.2.2",
+		},
+		{
+			name:       "x-forwarded-for only trusted",
+			header:     "X-Forwarded-For",
+			remoteAddr: "10.0.0.1:80",
+			headers:    map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"},
+			expected:   "10.0.0.3",
+		},
+		{
+			name:       "x-forwarded-for invalid",
+			header:     "X-Forwarded-For",
+			remoteAddr: "10.0.0.1:80",
+			headers:    map[string]string{"X-Forwarded-For": "2.2.2.2, invalid"},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "x-real-ip",
+			header:     "X-Real-IP",
+			remoteAddr: "10.0.0.1:80",
+			headers:    map[string]string{"X-Real-IP": "2.2.2.2"},
+			expected:   "2.2.2.2",
+		},
+		{
+			name:       "forwarded",
+			header:     "Forwarded",
+			remoteAddr: "[fd00::1]:80",
+			headers: map[string]string{
+				"Forwarded": `for=3.3.3.3, for="[2001:db8::1]:4711";proto=https`,
+				"X-Real-IP": "4.4.4.4",
+			},
+			expected: "2001:db8::1",
+		},
+		{
+			name:       "spoofed forwarded",
+			header:     "X-Forwarded-For",
+			remoteAddr: "10.0.0.1:80",
+			headers: map[string]string{
+				"Forwarded":       "for=1.2.3.4",
+				"X-Forwarded-For": "2.2.2.2",
+			},
+			expected: "2.2.2.2",
+		},
+		{
+			name:       "spoofed x-forwarded-for",
+			header:     "X-Real-IP",
+			remoteAddr: "10.0.0.1:80",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "forwarded obfuscated",
+			header:     "Forwarded",
+			remoteAddr: "10.0.0.1:80",
+			headers:    map[string]string{"Forwarded": "for=_hidden"},
+			expected:   "10.0.0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, resolveIP(t, tt.header, tt.remoteAddr, tt.headers))
+		})
+	}
+}
+
+func TestRealIPInvalidProxy(t *testing.T) {
+	_, err := middleware.RealIP([]string{"invalid"})
+	assert.NotNil(t, err)
+}
+
+func TestRateLimitRealIP(t *testing.T) {
+	realIP, err := middleware.RealIP([]string{"10.0.0.0/8"})
+	require.Nil(t, err)
+
+	rateLimit := middleware.RateLimit(1, 1, time.Minute, time.Minute)
+	handler := func(next http.Handler) http.Handler {
+		return realIP(rateLimit(next))
+	}
+
+	for _, client := range []string{"1.1.1.1", "2.2.2.2"} {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+		req.RemoteAddr = "10.0.0.1:80"
+		req.Header.Set("X-Forwarded-For", client)
+
+		res := testMiddleware(t, handler, req, nil)
+		assert.Equal(t, http.StatusOK, res.Code)
+	}
+}
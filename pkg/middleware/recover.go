@@ -1,14 +1,24 @@
 package middleware
 
 import (
+	"fmt"
 	"log/slog"
 	"net/http"
 	"runtime/debug"
 
 	"github.com/XDoubleU/essentia/internal/shared"
+	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+	errortools "github.com/XDoubleU/essentia/pkg/errors"
+	"github.com/getsentry/sentry-go"
 )
 
 // Recover is middleware used to recover from a panic.
+// When the response wasn't started yet, the response of
+// [httptools.ServerErrorResponse] is returned. The panic is captured with its
+// stack trace by the [sentry.Hub] of the context, so Recover should be used
+// after [sentrytools.Middleware]. Panics with [http.ErrAbortHandler] are
+// passed on, so the server aborts the response without logging them.
 func Recover(logger *slog.Logger) shared.Middleware {
 	return func(next http.Handler) http.Handler {
 		return recoverHandler(logger, next)
@@ -17,19 +27,60 @@ func Recover(logger *slog.Logger) shared.Middleware {
 
 func recoverHandler(logger *slog.Logger, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := httptools.NewResponseWriter(w)
+
 		defer func() {
-			if err := recover(); err != nil {
-				w.Header().Set("connection", "close")
-				w.WriteHeader(http.StatusInternalServerError)
-				logger.ErrorContext(
-					r.Context(),
-					"PANIC",
-					slog.Any("error", err),
-					slog.String("stacktrace", string(debug.Stack())),
-				)
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			//nolint:errorlint //http.ErrAbortHandler is never wrapped
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			err := panicToError(recovered)
+
+			// panics passed on by Timeout contain the stack of the handler
+			stack := debug.Stack()
+			if p, ok := recovered.(handlerPanic); ok {
+				recovered, stack = p.value, p.stack
 			}
+
+			logger.ErrorContext(
+				r.Context(),
+				"PANIC",
+				slog.Any("error", recovered),
+				slog.String("stacktrace", string(stack)),
+			)
+
+			if hub := sentry.GetHubFromContext(r.Context()); hub != nil {
+				hub.RecoverWithContext(r.Context(), err)
+			}
+
+			if rw.Status() != -1 {
+				return
+			}
+
+			rw.Header().Set("Connection", "close")
+
+			message := errortools.MessageInternalServerError
+			if contexttools.ShowErrors(r.Context()) {
+				message = err.Error()
+			}
+
+			httptools.ErrorResponse(rw, r, http.StatusInternalServerError, message)
 		}()
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(rw, r)
 	})
 }
+
+func panicToError(recovered any) error {
+	if err, ok := recovered.(error); ok {
+		return err
+	}
+
+	return fmt.Errorf("%v", recovered)
+}
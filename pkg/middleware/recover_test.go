@@ -0,0 +1,93 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/XDoubleU/essentia/internal/mocks"
+	errortools "github.com/XDoubleU/essentia/pkg/errors"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	sentrytools "github.com/XDoubleU/essentia/pkg/sentry"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverErrorDto(t *testing.T) {
+	var captured *sentry.Event
+
+	clientOptions := sentrytools.MockedSentryClientOptions()
+	clientOptions.BeforeSend = func(
+		event *sentry.Event,
+		_ *sentry.EventHint,
+	) *sentry.Event {
+		captured = event
+		return event
+	}
+
+	client, err := sentry.NewClient(clientOptions)
+	require.Nil(t, err)
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	req = req.WithContext(sentry.SetHubOnContext(req.Context(), hub))
+
+	res := testMiddleware(
+		t,
+		middleware.Recover(logging.NewNopLogger()),
+		req,
+		func(_ http.ResponseWriter, _ *http.Request) {
+			panic("test")
+		},
+	)
+
+	var errorDto errortools.ErrorDto
+	err = json.NewDecoder(res.Body).Decode(&errorDto)
+	require.Nil(t, err)
+
+	assert.Equal(t, http.StatusInternalServerError, errorDto.Status)
+	assert.Equal(t, errortools.MessageInternalServerError, errorDto.Message)
+
+	require.NotNil(t, captured)
+	assert.Equal(t, sentry.LevelFatal, captured.Level)
+	assert.Equal(t, "test", captured.Exception[0].Value)
+	assert.NotNil(t, captured.Exception[0].Stacktrace)
+}
+
+func TestRecoverStartedResponse(t *testing.T) {
+	mockedLogger := mocks.MockedLogger{}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	res := testMiddleware(
+		t,
+		middleware.Recover(mockedLogger.Logger()),
+		req,
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			panic("test")
+		},
+	)
+
+	assert.Equal(t, http.StatusAccepted, res.Result().StatusCode)
+	assert.Empty(t, res.Body.String())
+	assert.Contains(t, mockedLogger.CapturedLogs(), "PANIC")
+}
+
+func TestRecoverAbortHandler(t *testing.T) {
+	mockedLogger := mocks.MockedLogger{}
+
+	handler := middleware.Recover(mockedLogger.Logger())(
+		http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+			panic(http.ErrAbortHandler)
+		}),
+	)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+	assert.Empty(t, mockedLogger.CapturedLogs())
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/XDoubleU/essentia/internal/shared"
+	"github.com/XDoubleU/essentia/pkg/context"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header used to receive and return the ID of a request.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// RequestID is middleware used to identify every request. The ID of the
+// [RequestIDHeader] header is used when valid, otherwise a UUID is generated.
+// The ID is stored in the context and returned in the [RequestIDHeader]
+// header. It's added to every [errortools.ErrorDto] and, when RequestID
+// is used before them, to the logs of [Logger] and the Sentry scope.
+func RequestID() shared.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !isValidRequestID(id) {
+				id = uuid.NewString()
+			}
+
+			w.Header().Set(RequestIDHeader, id)
+
+			ctx := context.WithRequestID(r.Context(), id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// isValidRequestID prevents IDs which could be used
+// to inject values into logs or response headers.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, char := range id {
+		if char < '!' || char > '~' {
+			return false
+		}
+	}
+
+	return true
+}
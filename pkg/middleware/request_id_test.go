@@ -0,0 +1,67 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+	"github.com/XDoubleU/essentia/pkg/context"
+	errortools "github.com/XDoubleU/essentia/pkg/errors"
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	req.Header.Set(middleware.RequestIDHeader, "abc-123")
+
+	var requestID string
+	res := testMiddleware(
+		t,
+		middleware.RequestID(),
+		req,
+		func(_ http.ResponseWriter, r *http.Request) {
+			requestID = context.RequestID(r.Context())
+		},
+	)
+
+	assert.Equal(t, "abc-123", requestID)
+	assert.Equal(t, "abc-123", res.Header().Get(middleware.RequestIDHeader))
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	for _, header := range []string{"", "a b", strings.Repeat("a", 129)} {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+		req.Header.Set(middleware.RequestIDHeader, header)
+
+		res := testMiddleware(t, middleware.RequestID(), req, nil)
+
+		_, err := uuid.Parse(res.Header().Get(middleware.RequestIDHeader))
+		assert.Nil(t, err)
+	}
+}
+
+func TestRequestIDErrorDto(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	req.Header.Set(middleware.RequestIDHeader, "abc-123")
+
+	res := testMiddleware(
+		t,
+		middleware.RequestID(),
+		req,
+		func(w http.ResponseWriter, r *http.Request) {
+			httptools.ForbiddenResponse(w, r)
+		},
+	)
+
+	var errorDto errortools.ErrorDto
+	err := json.NewDecoder(res.Body).Decode(&errorDto)
+	require.Nil(t, err)
+
+	assert.Equal(t, http.StatusForbidden, errorDto.Status)
+	assert.Equal(t, "abc-123", errorDto.RequestID)
+}
@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/XDoubleU/essentia/internal/shared"
+	sentrytools "github.com/XDoubleU/essentia/pkg/sentry"
+	"github.com/getsentry/sentry-go"
+	"github.com/goddtriffin/helmet"
+	"github.com/justinas/alice"
+	"github.com/rs/cors"
+)
+
+// Position names the position of a component in a stack built by [Stack].
+type Position string
+
+// Positions of the components of a stack built by [Stack], in order.
+const (
+	PositionRealIP       Position = "real_ip"
+	PositionRequestID    Position = "request_id"
+	PositionLogger       Position = "logger"
+	PositionSentry       Position = "sentry"
+	PositionTracing      Position = "tracing"
+	PositionRecover      Position = "recover"
+	PositionMaintenance  Position = "maintenance"
+	PositionLoadShedding Position = "load_shedding"
+	PositionHelmet       Position = "helmet"
+	PositionCORS         Position = "cors"
+	PositionCompress     Position = "compress"
+	PositionRateLimit    Position = "rate_limit"
+	PositionTimeout      Position = "timeout"
+)
+
+//nolint:gochecknoglobals //used as constant
+var positions = []Position{
+	PositionRealIP,
+	PositionRequestID,
+	PositionLogger,
+	PositionSentry,
+	PositionTracing,
+	PositionRecover,
+	PositionMaintenance,
+	PositionLoadShedding,
+	PositionHelmet,
+	PositionCORS,
+	PositionCompress,
+	PositionRateLimit,
+	PositionTimeout,
+}
+
+// StackOption configures a component of a stack built by [Stack].
+type StackOption func(options *stackOptions)
+
+type component = func() (shared.Middleware, error)
+
+type stackOptions struct {
+	logger        *slog.Logger
+	loggerOptions []LoggerOption
+	components    map[Position]component
+	before        map[Position][]shared.Middleware
+	after         map[Position][]shared.Middleware
+}
+
+// WithRealIP adds [RealIP] using the provided trusted proxies
+// and [RealIPOption]s.
+func WithRealIP(trustedProxies []string, realIPOptions ...RealIPOption) StackOption {
+	return func(options *stackOptions) {
+		options.components[PositionRealIP] = func() (shared.Middleware, error) {
+			return RealIP(trustedProxies, realIPOptions...)
+		}
+	}
+}
+
+// WithRequestID adds [RequestID].
+func WithRequestID() StackOption {
+	return withMiddleware(PositionRequestID, RequestID())
+}
+
+// WithLoggerOptions configures [Logger] using the provided [LoggerOption]s.
+func WithLoggerOptions(loggerOptions ...LoggerOption) StackOption {
+	return func(options *stackOptions) {
+		options.loggerOptions = loggerOptions
+	}
+}
+
+// WithSentry adds [sentrytools.Middleware].
+func WithSentry(env string, clientOptions sentry.ClientOptions) StackOption {
+	return func(options *stackOptions) {
+		options.components[PositionSentry] = func() (shared.Middleware, error) {
+			return sentrytools.Middleware(env, clientOptions)
+		}
+	}
+}
+
+// WithTracing adds [Tracing] using the provided [Router], which can be nil.
+func WithTracing(router Router) StackOption {
+	return withMiddleware(PositionTracing, Tracing(router))
+}
+
+// WithMaintenance adds maintenance mode using the provided [Maintenance].
+func WithMaintenance(maintenance *Maintenance) StackOption {
+	return withMiddleware(PositionMaintenance, maintenance.Middleware())
+}
+
+// WithHelmet adds [helmet.Helmet] using the provided settings.
+func WithHelmet(helmet *helmet.Helmet) StackOption {
+	return withMiddleware(PositionHelmet, helmet.Secure)
+}
+
+// WithCORS adds CORS using the provided [cors.Options].
+// [CORS] provides sensible defaults.
+func WithCORS(corsOptions cors.Options) StackOption {
+	return withMiddleware(PositionCORS, cors.New(corsOptions).Handler)
+}
+
+// WithCompression adds [Compress] using the provided [CompressOption]s.
+// Compression isn't part of [Default], as compressing responses containing
+// secrets next to attacker-controlled data exposes these to BREACH.
+func WithCompression(compressOptions ...CompressOption) StackOption {
+	return func(options *stackOptions) {
+		options.components[PositionCompress] = func() (shared.Middleware, error) {
+			return Compress(compressOptions...)
+		}
+	}
+}
+
+// WithRateLimit adds rate limiting of all requests
+// using the provided [RateLimiter] and [Limit].
+func WithRateLimit(limiter *RateLimiter, limit Limit) StackOption {
+	return withMiddleware(PositionRateLimit, limiter.Limit("", limit))
+}
+
+// WithLoadShedding adds limiting of the amount of requests handled at once
+// using the provided [ConcurrencyLimiter], except for exemptPaths.
+func WithLoadShedding(limiter *ConcurrencyLimiter, exemptPaths ...string) StackOption {
+	return withMiddleware(PositionLoadShedding, limiter.Middleware(exemptPaths...))
+}
+
+// WithTimeout adds [Timeout] using the provided timeout and [TimeoutOption]s.
+func WithTimeout(timeout time.Duration, timeoutOptions ...TimeoutOption) StackOption {
+	return withMiddleware(PositionTimeout, Timeout(timeout, timeoutOptions...))
+}
+
+// Without removes the components at the provided positions.
+// Middleware inserted before or after these positions is kept.
+func Without(positions ...Position) StackOption {
+	return func(options *stackOptions) {
+		for _, position := range positions {
+			delete(options.components, position)
+		}
+	}
+}
+
+// WithBefore inserts custom middleware before the provided position.
+func WithBefore(position Position, middleware ...shared.Middleware) StackOption {
+	return func(options *stackOptions) {
+		options.before[position] = append(options.before[position], middleware...)
+	}
+}
+
+// WithAfter inserts custom middleware after the provided position.
+func WithAfter(position Position, middleware ...shared.Middleware) StackOption {
+	return func(options *stackOptions) {
+		options.after[position] = append(options.after[position], middleware...)
+	}
+}
+
+func withMiddleware(position Position, middleware shared.Middleware) StackOption {
+	return func(options *stackOptions) {
+		options.components[position] = func() (shared.Middleware, error) {
+			return middleware, nil
+		}
+	}
+}
+
+// Stack builds a chain of middleware. By default this contains
+// [Logger] and [Recover], other components are added using
+// [StackOption]s. Components are always ordered as listed by
+// the Position constants, so for example [RealIP] and [RequestID]
+// run before [Logger] and [Recover] runs after [sentrytools.Middleware].
+func Stack(
+	logger *slog.Logger,
+	options ...StackOption,
+) ([]alice.Constructor, error) {
+	stack := stackOptions{
+		logger:        logger,
+		loggerOptions: []LoggerOption{},
+		components:    make(map[Position]component),
+		before:        make(map[Position][]shared.Middleware),
+		after:         make(map[Position][]shared.Middleware),
+	}
+
+	stack.components[PositionLogger] = func() (shared.Middleware, error) {
+		return Logger(stack.logger, stack.loggerOptions...), nil
+	}
+	stack.components[PositionRecover] = func() (shared.Middleware, error) {
+		return Recover(stack.logger), nil
+	}
+
+	for _, option := range options {
+		option(&stack)
+	}
+
+	handlers := []alice.Constructor{}
+	for _, position := range positions {
+		for _, middleware := range stack.before[position] {
+			handlers = append(handlers, middleware)
+		}
+
+		if component, ok := stack.components[position]; ok {
+			middleware, err := component()
+			if err != nil {
+				return nil, err
+			}
+
+			handlers = append(handlers, middleware)
+		}
+
+		for _, middleware := range stack.after[position] {
+			handlers = append(handlers, middleware)
+		}
+	}
+
+	return handlers, nil
+}
@@ -0,0 +1,175 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/XDoubleU/essentia/pkg/config"
+	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	sentrytools "github.com/XDoubleU/essentia/pkg/sentry"
+	"github.com/getsentry/sentry-go"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func marker(name string, order *[]string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*order = append(*order, name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func serveStack(
+	t *testing.T,
+	handlers []alice.Constructor,
+	req *http.Request,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	res := httptest.NewRecorder()
+	alice.New(handlers...).ThenFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	).ServeHTTP(res, req)
+
+	return res
+}
+
+func TestStack(t *testing.T) {
+	handlers, err := middleware.Stack(logging.NewNopLogger())
+	require.Nil(t, err)
+	assert.Len(t, handlers, 2)
+
+	handlers = middleware.Minimal(logging.NewNopLogger())
+	assert.Len(t, handlers, 2)
+
+	handlers, err = middleware.Default(logging.NewNopLogger(), []string{})
+	require.Nil(t, err)
+	assert.Len(t, handlers, 5)
+
+	handlers, err = middleware.Default(
+		logging.NewNopLogger(),
+		[]string{},
+		middleware.WithCompression(),
+	)
+	require.Nil(t, err)
+	assert.Len(t, handlers, 6)
+
+	handlers, err = middleware.DefaultWithSentry(
+		logging.NewNopLogger(),
+		[]string{},
+		config.TestEnv,
+		sentrytools.MockedSentryClientOptions(),
+	)
+	require.Nil(t, err)
+	assert.Len(t, handlers, 6)
+}
+
+func TestStackPositions(t *testing.T) {
+	order := []string{}
+
+	handlers, err := middleware.Default(
+		logging.NewNopLogger(),
+		[]string{"http://example.com"},
+		middleware.WithRequestID(),
+		middleware.Without(middleware.PositionRateLimit),
+		middleware.WithBefore(middleware.PositionLogger, marker("before", &order)),
+		middleware.WithAfter(middleware.PositionRecover, marker("after", &order)),
+		middleware.WithAfter(
+			middleware.PositionRateLimit,
+			marker("rate_limit", &order),
+		),
+	)
+	require.Nil(t, err)
+	assert.Len(t, handlers, 8)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	req.RemoteAddr = "127.0.0.1:80"
+
+	for range 31 {
+		res := serveStack(t, handlers, req)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.NotEmpty(t, res.Header().Get(middleware.RequestIDHeader))
+	}
+
+	assert.Equal(t, []string{"before", "after", "rate_limit"}, order[0:3])
+}
+
+func TestStackInvalidRealIP(t *testing.T) {
+	_, err := middleware.Stack(
+		logging.NewNopLogger(),
+		middleware.WithRealIP([]string{"invalid"}),
+	)
+	assert.NotNil(t, err)
+}
+
+func TestDefaultCORS(t *testing.T) {
+	handlers, err := middleware.Default(
+		logging.NewNopLogger(),
+		[]string{"http://example.com"},
+	)
+	require.Nil(t, err)
+
+	req, _ := http.NewRequest(http.MethodOptions, "http://example.com/foo", nil)
+	req.Header.Set("Origin", "http://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	req.Header.Set("Access-Control-Request-Headers", "authorization")
+
+	res := serveStack(t, handlers, req)
+	assert.Equal(t, http.MethodPut, res.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(
+		t,
+		"authorization",
+		res.Header().Get("Access-Control-Allow-Headers"),
+	)
+}
+
+func TestDefaultWithSentryAbortHandler(t *testing.T) {
+	events := 0
+
+	clientOptions := sentrytools.MockedSentryClientOptions()
+	clientOptions.BeforeSend = func(
+		_ *sentry.Event,
+		_ *sentry.EventHint,
+	) *sentry.Event {
+		events++
+		return nil
+	}
+
+	handlers, err := middleware.DefaultWithSentry(
+		logging.NewNopLogger(),
+		[]string{},
+		config.ProdEnv,
+		clientOptions,
+	)
+	require.Nil(t, err)
+
+	handler := alice.New(handlers...).ThenFunc(
+		func(_ http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/abort" {
+				panic(http.ErrAbortHandler)
+			}
+			panic("test")
+		},
+	)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/abort", nil)
+	req.RemoteAddr = "127.0.0.1:80"
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+	assert.Equal(t, 0, events)
+
+	req, _ = http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	req.RemoteAddr = "127.0.0.1:80"
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusInternalServerError, res.Result().StatusCode)
+	assert.Equal(t, 1, events)
+}
@@ -0,0 +1,274 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/XDoubleU/essentia/internal/shared"
+	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+	"github.com/getsentry/sentry-go"
+)
+
+// TimeoutOption configures [Timeout].
+type TimeoutOption func(options *timeoutOptions)
+
+type timeoutOptions struct {
+	router Router
+	routes map[string]time.Duration
+	status int
+}
+
+// WithRouteTimeouts sets the timeout of requests to the route patterns
+// found by router, overriding the default timeout. A timeout of 0
+// disables the timeout, for example for streaming or websocket routes.
+func WithRouteTimeouts(router Router, timeouts map[string]time.Duration) TimeoutOption {
+	return func(options *timeoutOptions) {
+		options.router = router
+		options.routes = timeouts
+	}
+}
+
+// WithTimeoutStatus sets the status of responses to requests which timed
+// out, being [http.StatusServiceUnavailable] by default or
+// [http.StatusGatewayTimeout].
+func WithTimeoutStatus(status int) TimeoutOption {
+	return func(options *timeoutOptions) {
+		options.status = status
+	}
+}
+
+// Timeout is middleware used to set a deadline on the context of requests.
+// When a handler didn't finish before the deadline, the request is answered
+// with [httptools.TimeoutResponse] and later writes of the handler fail with
+// [http.ErrHandlerTimeout]. Handlers should stop when their context is done.
+// Other panics are passed on as error keeping the stack trace of the
+// handler, which is reported by [Recover]. Panics of handlers which timed
+// out are logged using the logger of the context, as the response was
+// already written.
+// Like [http.TimeoutHandler], responses are buffered until the handler is
+// finished, so Flush and Hijack aren't supported and routes streaming
+// responses or upgrading to websockets shouldn't have a timeout.
+func Timeout(timeout time.Duration, options ...TimeoutOption) shared.Middleware {
+	timeoutOptions := timeoutOptions{
+		router: nil,
+		routes: map[string]time.Duration{},
+		status: http.StatusServiceUnavailable,
+	}
+
+	for _, option := range options {
+		option(&timeoutOptions)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			duration := timeoutOptions.timeout(r, timeout)
+			if duration <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), duration)
+			defer cancel()
+
+			r = r.WithContext(ctx)
+			tw := &timeoutWriter{
+				w:           w,
+				header:      make(http.Header),
+				buffer:      &bytes.Buffer{},
+				mu:          &sync.Mutex{},
+				status:      http.StatusOK,
+				wroteHeader: false,
+				timedOut:    false,
+			}
+
+			done := make(chan struct{})
+			panicChan := make(chan handlerPanic, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicChan <- newHandlerPanic(p)
+					}
+				}()
+
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicChan:
+				//nolint:errorlint //http.ErrAbortHandler is never wrapped
+				if p.value == http.ErrAbortHandler {
+					panic(p.value)
+				}
+
+				panic(p)
+			case <-done:
+				tw.flush()
+			case <-ctx.Done():
+				tw.timeout(r, timeoutOptions.status)
+				go logLatePanic(r, panicChan, done)
+			}
+		})
+	}
+}
+
+// maxPanicFrames is the maximum depth of the stack trace of a handlerPanic.
+const maxPanicFrames = 64
+
+// handlerPanic is a panic of a handler with the stack trace of the
+// goroutine running the handler. It's passed on as panic instead of its
+// value, so [Recover] and Sentry report where the handler panicked
+// instead of the goroutine of Timeout.
+type handlerPanic struct {
+	value any
+	stack []byte
+	pcs   []uintptr
+}
+
+func newHandlerPanic(value any) handlerPanic {
+	// keep the stack of the handler when Timeout is used more than once
+	if p, ok := value.(handlerPanic); ok {
+		return p
+	}
+
+	pcs := make([]uintptr, maxPanicFrames)
+	// skip runtime.Callers, newHandlerPanic and the deferred function
+	//nolint:mnd //amount of frames to skip
+	amount := runtime.Callers(3, pcs)
+
+	return handlerPanic{
+		value: value,
+		stack: debug.Stack(),
+		pcs:   pcs[:amount],
+	}
+}
+
+// Error returns the value of a panic as string.
+func (p handlerPanic) Error() string {
+	return fmt.Sprint(p.value)
+}
+
+// Unwrap returns the value of a panic if it's an error.
+func (p handlerPanic) Unwrap() error {
+	err, _ := p.value.(error)
+	return err
+}
+
+// StackTrace returns the program counters of the stack of a panic,
+// which Sentry uses as stack trace of the error.
+func (p handlerPanic) StackTrace() []uintptr {
+	return p.pcs
+}
+
+// logLatePanic logs and captures a panic of a handler which timed out,
+// as it can't be passed on to [Recover] anymore.
+func logLatePanic(r *http.Request, panicChan chan handlerPanic, done chan struct{}) {
+	select {
+	case <-done:
+	case p := <-panicChan:
+		//nolint:errorlint //http.ErrAbortHandler is never wrapped
+		if p.value == http.ErrAbortHandler {
+			return
+		}
+
+		contexttools.Logger(r.Context()).ErrorContext(
+			r.Context(),
+			"PANIC after timeout",
+			slog.Any("error", p.value),
+			slog.String("stacktrace", string(p.stack)),
+		)
+
+		if hub := sentry.GetHubFromContext(r.Context()); hub != nil {
+			hub.RecoverWithContext(r.Context(), p)
+		}
+	}
+}
+
+func (options timeoutOptions) timeout(
+	r *http.Request,
+	defaultTimeout time.Duration,
+) time.Duration {
+	if options.router == nil {
+		return defaultTimeout
+	}
+
+	_, route := options.router.Handler(r)
+	if timeout, ok := options.routes[route]; ok {
+		return timeout
+	}
+
+	return defaultTimeout
+}
+
+// timeoutWriter buffers the response of a handler,
+// which is only written when it finished in time.
+type timeoutWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	buffer      *bytes.Buffer
+	mu          *sync.Mutex
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+// Header returns the headers of the buffered response.
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// Write buffers data, failing when the request timed out.
+func (tw *timeoutWriter) Write(data []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	tw.wroteHeader = true
+	return tw.buffer.Write(data)
+}
+
+// WriteHeader sets the status of the buffered response.
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+
+	tw.wroteHeader = true
+	tw.status = status
+}
+
+func (tw *timeoutWriter) flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	dst := tw.w.Header()
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+
+	tw.w.WriteHeader(tw.status)
+	_, _ = tw.w.Write(tw.buffer.Bytes())
+}
+
+func (tw *timeoutWriter) timeout(r *http.Request, status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.timedOut = true
+	httptools.TimeoutResponse(tw.w, r, status)
+}
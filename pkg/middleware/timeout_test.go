@@ -0,0 +1,158 @@
+package middleware_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+	errortools "github.com/XDoubleU/essentia/pkg/errors"
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func timeoutRequest(
+	t *testing.T,
+	handler http.Handler,
+	path string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com"+path, nil)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	return res
+}
+
+func slowHandler(delay time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+
+		w.Header().Set("X-Test", "test")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("done"))
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	timeout := middleware.Timeout(20 * time.Millisecond)
+
+	res := timeoutRequest(t, timeout(slowHandler(0)), "/")
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, "test", res.Header().Get("X-Test"))
+	assert.Equal(t, "done", res.Body.String())
+
+	res = timeoutRequest(t, timeout(slowHandler(time.Second)), "/")
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Empty(t, res.Header().Get("X-Test"))
+
+	var errorDto errortools.ErrorDto
+	require.Nil(t, httptools.ReadJSON(res.Body, &errorDto))
+	assert.Equal(t, errortools.MessageTimeout, errorDto.Message)
+}
+
+func TestTimeoutRoutes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/slow", slowHandler(50*time.Millisecond))
+	mux.Handle("/stream", slowHandler(50*time.Millisecond))
+	mux.Handle("/fast", slowHandler(50*time.Millisecond))
+
+	handler := middleware.Timeout(
+		20*time.Millisecond,
+		middleware.WithRouteTimeouts(mux, map[string]time.Duration{
+			"/slow":   time.Second,
+			"/stream": 0,
+		}),
+		middleware.WithTimeoutStatus(http.StatusGatewayTimeout),
+	)(mux)
+
+	assert.Equal(t, http.StatusCreated, timeoutRequest(t, handler, "/slow").Code)
+	assert.Equal(t, http.StatusCreated, timeoutRequest(t, handler, "/stream").Code)
+	assert.Equal(t, http.StatusGatewayTimeout, timeoutRequest(t, handler, "/fast").Code)
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	handler := middleware.Timeout(time.Second)(
+		http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+			panic("test")
+		}),
+	)
+
+	assert.PanicsWithError(t, "test", func() {
+		timeoutRequest(t, handler, "/")
+	})
+}
+
+func panickingHandler(_ http.ResponseWriter, _ *http.Request) {
+	panic("test")
+}
+
+func TestTimeoutPanicStack(t *testing.T) {
+	logs := &lockedBuffer{}
+	logger := slog.New(slog.NewTextHandler(logs, nil))
+
+	handler := middleware.Recover(logger)(
+		middleware.Timeout(time.Second)(http.HandlerFunc(panickingHandler)),
+	)
+
+	res := timeoutRequest(t, handler, "/")
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+
+	assert.Contains(t, logs.String(), "error=test")
+	assert.Contains(t, logs.String(), "middleware_test.panickingHandler")
+}
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestTimeoutPanicAfterDeadline(t *testing.T) {
+	//nolint:exhaustruct //zero values are usable
+	logs := &lockedBuffer{}
+	logger := slog.New(slog.NewTextHandler(logs, nil))
+
+	handler := middleware.Timeout(20 * time.Millisecond)(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			panic("late")
+		}),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	req = req.WithContext(contexttools.WithLogger(req.Context(), logger))
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Eventually(t, func() bool {
+		return strings.Contains(logs.String(), "PANIC after timeout")
+	}, time.Second, 10*time.Millisecond)
+	assert.Contains(t, logs.String(), "late")
+}
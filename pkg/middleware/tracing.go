@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/XDoubleU/essentia/internal/shared"
+	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+	"github.com/XDoubleU/essentia/pkg/tracing"
+)
+
+// Tracing is middleware used to start a [tracing.Span] for every request,
+// continuing the trace of the traceparent header of the request, if any.
+// Spans are named after the method and the pattern of the route found by
+// router, which can be nil. Requests resulting in a server error are
+// marked as failed. The span is stored in the context of the request, so
+// spans started while handling it, such as database queries, are its children.
+func Tracing(router Router) shared.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := ""
+			if router != nil {
+				_, route = router.Handler(r)
+			}
+
+			name := r.Method
+			if route != "" {
+				name += " " + route
+			}
+
+			ctx := tracing.Extract(r.Context(), r.Header)
+			ctx, span := tracing.Start(
+				ctx,
+				name,
+				tracing.WithKind(tracing.SpanKindServer),
+				tracing.WithOperation("http.server"),
+				tracing.WithAttribute("http.request.method", r.Method),
+				tracing.WithAttribute("url.path", r.URL.Path),
+				tracing.WithAttribute("http.route", route),
+				tracing.WithAttribute("client.address", ClientIP(r)),
+				tracing.WithAttribute("user_agent.original", r.UserAgent()),
+			)
+			defer span.End()
+
+			rw := httptools.NewResponseWriter(w)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			status := rw.Status()
+			if status == -1 {
+				status = http.StatusOK
+			}
+
+			span.SetAttribute("http.response.status_code", status)
+			if status >= http.StatusInternalServerError {
+				span.RecordError(errors.New(http.StatusText(status)))
+			}
+		})
+	}
+}
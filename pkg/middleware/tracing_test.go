@@ -0,0 +1,107 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/XDoubleU/essentia/pkg/middleware"
+	"github.com/XDoubleU/essentia/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func useOTelTracer(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	tracing.SetTracer(tracing.NewOTelTracer(provider))
+	t.Cleanup(func() {
+		tracing.SetTracer(tracing.NewSentryTracer())
+		_ = provider.Shutdown(context.Background())
+	})
+
+	return exporter
+}
+
+func TestTracing(t *testing.T) {
+	exporter := useOTelTracer(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{id}", func(_ http.ResponseWriter, _ *http.Request) {})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/users/1", nil)
+	req.Header.Set(tracing.TraceparentHeader, traceparent)
+
+	testMiddleware(
+		t,
+		middleware.Tracing(mux),
+		req,
+		func(_ http.ResponseWriter, r *http.Request) {
+			_, span := tracing.Start(r.Context(), "child")
+			span.End()
+		},
+	)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	child, server := spans[0], spans[1]
+	assert.Equal(t, "GET /users/{id}", server.Name)
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(
+		t,
+		"4bf92f3577b34da6a3ce929d0e0e4736",
+		server.SpanContext.TraceID().String(),
+	)
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+	assert.Contains(
+		t,
+		server.Attributes,
+		attribute.String("http.route", "/users/{id}"),
+	)
+	assert.Contains(t, server.Attributes, attribute.String("url.path", "/users/1"))
+	assert.Contains(
+		t,
+		server.Attributes,
+		attribute.Int("http.response.status_code", http.StatusOK),
+	)
+	assert.Equal(t, codes.Unset, server.Status.Code)
+
+	assert.Equal(t, server.SpanContext.SpanID(), child.Parent.SpanID())
+}
+
+func TestTracingServerError(t *testing.T) {
+	exporter := useOTelTracer(t)
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/foo", nil)
+
+	testMiddleware(
+		t,
+		middleware.Tracing(nil),
+		req,
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		},
+	)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "POST", spans[0].Name)
+	assert.False(t, spans[0].Parent.IsValid())
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Contains(
+		t,
+		spans[0].Attributes,
+		attribute.Int("http.response.status_code", http.StatusInternalServerError),
+	)
+}
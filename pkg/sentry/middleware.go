@@ -5,12 +5,17 @@ import (
 
 	"github.com/XDoubleU/essentia/internal/shared"
 	"github.com/XDoubleU/essentia/pkg/config"
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
 	"github.com/getsentry/sentry-go"
 	sentryhttp "github.com/getsentry/sentry-go/http"
 )
 
 // Middleware is middleware used to configure and enable Sentry.
 // When env is [config.TestEnv], a mocked [sentry.Hub] will be used.
+// The IP of the client and the ID of the request stored in the context,
+// for example by middleware.RealIP and middleware.RequestID, are set on
+// the scope as IP of the user and request_id tag. Panics with
+// [http.ErrAbortHandler] aren't captured, but are passed on.
 func Middleware(
 	env string,
 	clientOptions sentry.ClientOptions,
@@ -28,15 +33,45 @@ func Middleware(
 
 	if isTestEnv {
 		return func(next http.Handler) http.Handler {
-			return sentryHandler.Handle(useMockedHub(next))
+			return passAborts(sentryHandler, useMockedHub(setScope(next)))
 		}, nil
 	}
 
 	return func(next http.Handler) http.Handler {
-		return sentryHandler.Handle(next)
+		return passAborts(sentryHandler, setScope(next))
 	}, nil
 }
 
+// passAborts recovers panics with [http.ErrAbortHandler] before they reach
+// sentryHandler, which would capture them, and panics again afterwards.
+func passAborts(sentryHandler *sentryhttp.Handler, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		aborted := false
+
+		sentryHandler.HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				recovered := recover()
+
+				//nolint:errorlint //http.ErrAbortHandler is never wrapped
+				if recovered == http.ErrAbortHandler {
+					aborted = true
+					return
+				}
+
+				if recovered != nil {
+					panic(recovered)
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})(w, r)
+
+		if aborted {
+			panic(http.ErrAbortHandler)
+		}
+	})
+}
+
 func getSentryHandler(clientOptions sentry.ClientOptions) (*sentryhttp.Handler, error) {
 	err := sentry.Init(clientOptions)
 
@@ -58,3 +93,24 @@ func useMockedHub(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+func setScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub := sentry.GetHubFromContext(r.Context())
+		if hub == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if ip := contexttools.ClientIP(r.Context()); ip != "" {
+			//nolint:exhaustruct //other fields are optional
+			hub.Scope().SetUser(sentry.User{IPAddress: ip})
+		}
+
+		if id := contexttools.RequestID(r.Context()); id != "" {
+			hub.Scope().SetTag("request_id", id)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
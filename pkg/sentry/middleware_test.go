@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/XDoubleU/essentia/pkg/config"
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
 	sentrytools "github.com/XDoubleU/essentia/pkg/sentry"
 	"github.com/getsentry/sentry-go"
 	"github.com/stretchr/testify/assert"
@@ -53,3 +54,29 @@ func TestMiddleware(t *testing.T) {
 		},
 	)
 }
+
+func TestMiddlewareScope(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	ctx := contexttools.WithClientIP(req.Context(), "1.1.1.1")
+	req = req.WithContext(contexttools.WithRequestID(ctx, "id"))
+
+	sentryMiddleware, err := sentrytools.Middleware(
+		config.TestEnv,
+		sentrytools.MockedSentryClientOptions(),
+	)
+	require.Nil(t, err)
+
+	testMiddleware(
+		t,
+		sentryMiddleware,
+		req,
+		func(_ http.ResponseWriter, r *http.Request) {
+			hub := sentry.GetHubFromContext(r.Context())
+
+			//nolint:exhaustruct //other fields are optional
+			event := hub.Scope().ApplyToEvent(&sentry.Event{}, nil, hub.Client())
+			assert.Equal(t, "1.1.1.1", event.User.IPAddress)
+			assert.Equal(t, "id", event.Tags["request_id"])
+		},
+	)
+}
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// maxCookieSize is the maximum size of a cookie supported by browsers.
+const maxCookieSize = 4096
+
+// CookieStore is a [Store] storing sessions in the session cookie itself,
+// encrypted and authenticated using AES-GCM. As nothing is stored
+// server-side, deleting a session only removes the cookie, copies of
+// the cookie stay valid until the session expires.
+type CookieStore struct {
+	aead cipher.AEAD
+}
+
+type cookieRecord struct {
+	Record
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// NewCookieStore creates a new [CookieStore] using a key
+// of 16, 24 or 32 bytes, selecting AES-128, AES-192 or AES-256.
+func NewCookieStore(key []byte) (*CookieStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CookieStore{aead: aead}, nil
+}
+
+// Load decrypts a session, returning nil when it's invalid or expired.
+//
+//nolint:nilnil //invalid sessions are treated as missing
+func (s *CookieStore) Load(_ context.Context, token string) (*Record, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) < s.aead.NonceSize() {
+		return nil, nil
+	}
+
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, nil
+	}
+
+	var record cookieRecord
+	if err = json.Unmarshal(plaintext, &record); err != nil {
+		return nil, nil
+	}
+
+	if time.Now().After(record.ExpiresAt) {
+		return nil, nil
+	}
+
+	return &record.Record, nil
+}
+
+// Save encrypts a session.
+func (s *CookieStore) Save(
+	_ context.Context,
+	record Record,
+	expiresAt time.Time,
+) (string, error) {
+	plaintext, err := json.Marshal(cookieRecord{
+		Record:    record,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	data := s.aead.Seal(nonce, nonce, plaintext, nil)
+	token := base64.RawURLEncoding.EncodeToString(data)
+
+	if len(token) > maxCookieSize {
+		return "", errors.New("session is too large to store in a cookie")
+	}
+
+	return token, nil
+}
+
+// Delete does nothing, as the session is only stored in the cookie.
+func (s *CookieStore) Delete(_ context.Context, _ string) error {
+	return nil
+}
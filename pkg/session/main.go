@@ -0,0 +1,3 @@
+// Package session provides server-side sessions stored by a [Store],
+// such as the [CookieStore], which are managed by a [Manager].
+package session
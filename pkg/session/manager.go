@@ -0,0 +1,269 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"maps"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/XDoubleU/essentia/internal/shared"
+	httptools "github.com/XDoubleU/essentia/pkg/communication/http"
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+	"github.com/XDoubleU/essentia/pkg/logging"
+)
+
+const (
+	defaultCookieName      = "session"
+	defaultIdleTimeout     = 30 * time.Minute
+	defaultAbsoluteTimeout = 24 * time.Hour
+)
+
+// Option configures a [Manager].
+type Option func(manager *Manager)
+
+// Manager loads and saves the sessions of requests using a [Store].
+// Sessions expire when they weren't used for the idle timeout
+// or when they're older than the absolute timeout.
+type Manager struct {
+	store           Store
+	idleTimeout     time.Duration
+	absoluteTimeout time.Duration
+	cookie          http.Cookie
+	now             func() time.Time
+}
+
+// WithTimeouts sets the idle and absolute timeouts of sessions,
+// which are 30 minutes and 24 hours by default.
+func WithTimeouts(idle time.Duration, absolute time.Duration) Option {
+	return func(manager *Manager) {
+		manager.idleTimeout = idle
+		manager.absoluteTimeout = absolute
+	}
+}
+
+// WithCookie sets the name, path, domain, secure, HttpOnly and SameSite
+// attributes of the session cookie. By default the cookie is named session,
+// applies to all paths and is secure, HttpOnly and SameSite=Lax.
+func WithCookie(cookie http.Cookie) Option {
+	return func(manager *Manager) {
+		manager.cookie = cookie
+	}
+}
+
+// NewManager creates a new [Manager].
+func NewManager(store Store, options ...Option) *Manager {
+	manager := &Manager{
+		store:           store,
+		idleTimeout:     defaultIdleTimeout,
+		absoluteTimeout: defaultAbsoluteTimeout,
+		//nolint:exhaustruct //other fields are optional
+		cookie: http.Cookie{
+			Name:     defaultCookieName,
+			Path:     "/",
+			Secure:   true,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+		now: time.Now,
+	}
+
+	for _, option := range options {
+		option(manager)
+	}
+
+	return manager
+}
+
+// SetNow sets the function returning the current time, used for testing.
+func (m *Manager) SetNow(now func() time.Time) {
+	m.now = now
+}
+
+// Middleware is middleware used to load the [Session] of a request,
+// which is available through [FromContext], and save it before
+// the response is written. Sessions without values aren't saved and
+// changes made after the response was started are lost.
+func (m *Manager) Middleware() shared.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session, err := m.load(r)
+			if err != nil {
+				httptools.ServerErrorResponse(w, r, err)
+				return
+			}
+
+			ctx := WithSession(r.Context(), session)
+			sw := &sessionWriter{
+				ResponseWriter: w,
+				ctx:            ctx,
+				manager:        m,
+				session:        session,
+				once:           &sync.Once{},
+			}
+
+			next.ServeHTTP(sw, r.WithContext(ctx))
+			sw.commit()
+		})
+	}
+}
+
+func (m *Manager) load(r *http.Request) (*Session, error) {
+	now := m.now()
+
+	cookie, err := r.Cookie(m.cookie.Name)
+	if err != nil || cookie.Value == "" {
+		return newSession(now)
+	}
+
+	record, err := m.store.Load(r.Context(), cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if record == nil {
+		return newSession(now)
+	}
+
+	if now.After(m.expiresAt(*record)) {
+		err = m.store.Delete(r.Context(), cookie.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return newSession(now)
+	}
+
+	return loadedSession(*record, cookie.Value), nil
+}
+
+func (m *Manager) expiresAt(record Record) time.Time {
+	idle := record.LastSeenAt.Add(m.idleTimeout)
+	absolute := record.CreatedAt.Add(m.absoluteTimeout)
+
+	if idle.Before(absolute) {
+		return idle
+	}
+
+	return absolute
+}
+
+// save deletes the old tokens of a [Session] and saves it when it has values.
+// It returns the cookie which should be set, if any.
+func (m *Manager) save(ctx context.Context, session *Session) (*http.Cookie, error) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	for _, token := range session.oldTokens {
+		if err := m.store.Delete(ctx, token); err != nil {
+			return nil, err
+		}
+	}
+
+	hadCookie := session.token != "" || len(session.oldTokens) > 0
+	session.oldTokens = []string{}
+
+	cookie := m.cookie
+
+	if len(session.record.Values) == 0 {
+		if session.token != "" {
+			if err := m.store.Delete(ctx, session.token); err != nil {
+				return nil, err
+			}
+			session.token = ""
+		}
+
+		if !hadCookie {
+			//nolint:nilnil //no cookie has to be set
+			return nil, nil
+		}
+
+		cookie.MaxAge = -1
+		return &cookie, nil
+	}
+
+	session.record.LastSeenAt = m.now()
+
+	record := session.record
+	record.Values = maps.Clone(session.record.Values)
+	expiresAt := m.expiresAt(record)
+
+	token, err := m.store.Save(ctx, record, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	session.token = token
+
+	cookie.Value = token
+	cookie.Expires = expiresAt
+	return &cookie, nil
+}
+
+// sessionWriter saves the [Session] before the response is written,
+// so the session cookie can still be set.
+type sessionWriter struct {
+	http.ResponseWriter
+	ctx     context.Context
+	manager *Manager
+	session *Session
+	once    *sync.Once
+}
+
+func (w *sessionWriter) commit() {
+	w.once.Do(func() {
+		cookie, err := w.manager.save(w.ctx, w.session)
+		if err != nil {
+			contexttools.Logger(w.ctx).ErrorContext(
+				w.ctx,
+				"failed to save session",
+				logging.ErrAttr(err),
+			)
+			return
+		}
+
+		if cookie != nil {
+			http.SetCookie(w.ResponseWriter, cookie)
+		}
+	})
+}
+
+// WriteHeader saves the [Session] and sends the response headers.
+func (w *sessionWriter) WriteHeader(status int) {
+	w.commit()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write saves the [Session] and writes data to the response.
+func (w *sessionWriter) Write(data []byte) (int, error) {
+	w.commit()
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush saves the [Session] and sends any buffered data to the client.
+func (w *sessionWriter) Flush() {
+	w.commit()
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack saves the [Session] and lets the caller take over the connection.
+func (w *sessionWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.commit()
+
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijack not supported")
+	}
+
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the underlying [http.ResponseWriter].
+func (w *sessionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
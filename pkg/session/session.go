@@ -0,0 +1,173 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+	"time"
+
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+)
+
+const sessionContextKey = contexttools.Key("session")
+
+//nolint:mnd //256 bits
+const idLength = 32
+
+// Session is the session of a request, which is
+// available through [FromContext] when using [Manager.Middleware].
+type Session struct {
+	mu        *sync.Mutex
+	record    Record
+	token     string
+	oldTokens []string
+}
+
+func newSession(now time.Time) (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		mu: &sync.Mutex{},
+		record: Record{
+			ID:         id,
+			Values:     make(map[string]json.RawMessage),
+			CreatedAt:  now,
+			LastSeenAt: now,
+		},
+		token:     "",
+		oldTokens: []string{},
+	}, nil
+}
+
+func loadedSession(record Record, token string) *Session {
+	if record.Values == nil {
+		record.Values = make(map[string]json.RawMessage)
+	}
+
+	return &Session{
+		mu:        &sync.Mutex{},
+		record:    record,
+		token:     token,
+		oldTokens: []string{},
+	}
+}
+
+func newID() (string, error) {
+	id := make([]byte, idLength)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(id), nil
+}
+
+// WithSession sets the [Session] on the context.
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey, session)
+}
+
+// FromContext returns the [Session] stored in the context or nil.
+func FromContext(ctx context.Context) *Session {
+	session := contexttools.GetValue[*Session](ctx, sessionContextKey)
+	if session == nil {
+		return nil
+	}
+
+	return *session
+}
+
+// ID returns the ID of a [Session].
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.record.ID
+}
+
+// Get returns the value of key decoded into T and if it was found.
+func Get[T any](s *Session, key string) (T, bool) {
+	var value T
+
+	s.mu.Lock()
+	data, ok := s.record.Values[key]
+	s.mu.Unlock()
+
+	if !ok {
+		return value, false
+	}
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, false
+	}
+
+	return value, true
+}
+
+// Set sets the value of key, which has to be encodable as JSON.
+func (s *Session) Set(key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.record.Values[key] = data
+	return nil
+}
+
+// Delete deletes the value of key.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.record.Values, key)
+}
+
+// Regenerate gives a [Session] a new ID while keeping its values, which
+// should be done when logging in to prevent session fixation.
+func (s *Session) Regenerate() error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.renew(id)
+	return nil
+}
+
+// Destroy removes all values of a [Session] and deletes it from
+// the [Store], which should be done when logging out.
+func (s *Session) Destroy() error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.renew(id)
+	s.record.Values = make(map[string]json.RawMessage)
+	return nil
+}
+
+// renew gives a [Session] a new ID, marking its current token
+// to be deleted from the [Store]. The caller should hold the lock.
+func (s *Session) renew(id string) {
+	if s.token != "" {
+		s.oldTokens = append(s.oldTokens, s.token)
+		s.token = ""
+	}
+
+	s.record.ID = id
+}
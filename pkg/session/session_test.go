@@ -0,0 +1,219 @@
+package session_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/XDoubleU/essentia/pkg/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	Name string `json:"name"`
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]session.Record
+}
+
+func (s *memoryStore) Load(_ context.Context, token string) (*session.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[token]
+	if !ok {
+		//nolint:nilnil //a missing session isn't an error
+		return nil, nil
+	}
+
+	return &record, nil
+}
+
+func (s *memoryStore) Save(
+	_ context.Context,
+	record session.Record,
+	_ time.Time,
+) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[record.ID] = record
+	return record.ID, nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, token)
+	return nil
+}
+
+func newCookieStore(t *testing.T) *session.CookieStore {
+	t.Helper()
+
+	store, err := session.NewCookieStore([]byte("0123456789abcdef0123456789abcdef"))
+	require.Nil(t, err)
+
+	return store
+}
+
+func serve(
+	t *testing.T,
+	manager *session.Manager,
+	cookie *http.Cookie,
+	handler func(s *session.Session),
+) *http.Cookie {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+
+	res := httptest.NewRecorder()
+	manager.Middleware()(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s := session.FromContext(r.Context())
+			require.NotNil(t, s)
+
+			handler(s)
+			w.WriteHeader(http.StatusOK)
+		}),
+	).ServeHTTP(res, req)
+
+	cookies := res.Result().Cookies()
+	if len(cookies) == 0 {
+		return nil
+	}
+
+	return cookies[0]
+}
+
+func TestSession(t *testing.T) {
+	manager := session.NewManager(newCookieStore(t))
+
+	cookie := serve(t, manager, nil, func(s *session.Session) {
+		_, ok := session.Get[user](s, "user")
+		assert.False(t, ok)
+	})
+	assert.Nil(t, cookie)
+
+	cookie = serve(t, manager, nil, func(s *session.Session) {
+		require.Nil(t, s.Set("user", user{Name: "name"}))
+		require.Nil(t, s.Set("visits", 1))
+	})
+	require.NotNil(t, cookie)
+	assert.Equal(t, "session", cookie.Name)
+	assert.Equal(t, "/", cookie.Path)
+	assert.True(t, cookie.Secure)
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+
+	cookie = serve(t, manager, cookie, func(s *session.Session) {
+		value, ok := session.Get[user](s, "user")
+		assert.True(t, ok)
+		assert.Equal(t, "name", value.Name)
+
+		s.Delete("visits")
+	})
+	require.NotNil(t, cookie)
+
+	serve(t, manager, cookie, func(s *session.Session) {
+		_, ok := session.Get[int](s, "visits")
+		assert.False(t, ok)
+
+		_, ok = session.Get[int](s, "user")
+		assert.False(t, ok)
+	})
+
+	cookie.Value = "tampered" + cookie.Value
+	serve(t, manager, cookie, func(s *session.Session) {
+		_, ok := session.Get[user](s, "user")
+		assert.False(t, ok)
+	})
+}
+
+func TestSessionTimeouts(t *testing.T) {
+	now := time.Now()
+
+	manager := session.NewManager(
+		&memoryStore{mu: sync.Mutex{}, records: map[string]session.Record{}},
+		session.WithTimeouts(time.Minute, 3*time.Minute),
+	)
+	manager.SetNow(func() time.Time { return now })
+
+	cookie := serve(t, manager, nil, func(s *session.Session) {
+		require.Nil(t, s.Set("key", "value"))
+	})
+
+	for range 3 {
+		now = now.Add(50 * time.Second)
+
+		cookie = serve(t, manager, cookie, func(s *session.Session) {
+			_, ok := session.Get[string](s, "key")
+			assert.True(t, ok)
+		})
+	}
+
+	now = now.Add(50 * time.Second)
+	serve(t, manager, cookie, func(s *session.Session) {
+		_, ok := session.Get[string](s, "key")
+		assert.False(t, ok, "absolute timeout")
+	})
+
+	cookie = serve(t, manager, nil, func(s *session.Session) {
+		require.Nil(t, s.Set("key", "value"))
+	})
+
+	now = now.Add(2 * time.Minute)
+	serve(t, manager, cookie, func(s *session.Session) {
+		_, ok := session.Get[string](s, "key")
+		assert.False(t, ok, "idle timeout")
+	})
+}
+
+func TestSessionRegenerateDestroy(t *testing.T) {
+	store := &memoryStore{mu: sync.Mutex{}, records: map[string]session.Record{}}
+	manager := session.NewManager(store)
+
+	var id string
+	cookie := serve(t, manager, nil, func(s *session.Session) {
+		require.Nil(t, s.Set("key", "value"))
+		id = s.ID()
+	})
+
+	regenerated := serve(t, manager, cookie, func(s *session.Session) {
+		require.Nil(t, s.Regenerate())
+		assert.NotEqual(t, id, s.ID())
+	})
+	require.NotNil(t, regenerated)
+	assert.NotEqual(t, cookie.Value, regenerated.Value)
+
+	serve(t, manager, cookie, func(s *session.Session) {
+		_, ok := session.Get[string](s, "key")
+		assert.False(t, ok)
+	})
+
+	destroyed := serve(t, manager, regenerated, func(s *session.Session) {
+		value, ok := session.Get[string](s, "key")
+		assert.True(t, ok)
+		assert.Equal(t, "value", value)
+
+		require.Nil(t, s.Destroy())
+	})
+	require.NotNil(t, destroyed)
+	assert.Equal(t, -1, destroyed.MaxAge)
+	assert.Empty(t, store.records)
+}
+
+func TestCookieStoreInvalidKey(t *testing.T) {
+	_, err := session.NewCookieStore([]byte("short"))
+	assert.NotNil(t, err)
+}
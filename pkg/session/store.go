@@ -0,0 +1,9 @@
+package session
+
+import "github.com/XDoubleU/essentia/pkg/stores"
+
+// Record is the state of a session persisted by a [Store].
+type Record = stores.SessionRecord
+
+// Store persists the [Record]s of sessions, see [stores.SessionStore].
+type Store = stores.SessionStore
@@ -0,0 +1,26 @@
+package stores
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// CachedResponse is a response stored by a [CacheStore].
+type CachedResponse struct {
+	Status    int
+	Header    http.Header
+	Body      []byte
+	Tags      []string
+	StoredAt  time.Time
+	ExpiresAt time.Time
+}
+
+// CacheStore stores the [CachedResponse]s of a response cache.
+// Get returns nil when the response doesn't exist or has expired.
+// InvalidateTags removes all responses having any of the provided tags.
+type CacheStore interface {
+	Get(ctx context.Context, key string) (*CachedResponse, error)
+	Set(ctx context.Context, key string, response CachedResponse) error
+	InvalidateTags(ctx context.Context, tags ...string) error
+}
@@ -0,0 +1,4 @@
+// Package stores provides the interfaces and value types of the stores
+// used by the middleware and session packages, so stores such as those
+// of the postgres package don't depend on the packages using them.
+package stores
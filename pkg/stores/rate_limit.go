@@ -0,0 +1,95 @@
+package stores
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limit allows clients Burst requests at once,
+// after which tokens are refilled at Rate per second.
+type Limit struct {
+	Rate  rate.Limit
+	Burst int
+}
+
+// TokenBucket is the state of a client stored by a [RateLimitStore].
+// A zero TokenBucket is full.
+type TokenBucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// RateLimitResult is the outcome of taking a token of a [TokenBucket].
+// Reset is the time until the bucket is full again and RetryAfter
+// the time until a token is available when the request isn't allowed.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// RateLimitStore stores the [TokenBucket]s of a rate limiter.
+// Take should atomically take a token of the bucket of a key,
+// implementations can use [TakeToken] for this.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit Limit) (RateLimitResult, error)
+}
+
+// TakeToken takes a token of a [TokenBucket], after refilling it for the
+// time passed since it was last updated. The bucket is updated in place.
+// A now before the last update, for example of another instance with
+// a clock behind, doesn't move the update back, which would refill
+// that time twice.
+func TakeToken(bucket *TokenBucket, limit Limit, now time.Time) RateLimitResult {
+	burst := float64(limit.Burst)
+
+	if limit.Rate == rate.Inf {
+		return RateLimitResult{
+			Allowed:    true,
+			Limit:      limit.Burst,
+			Remaining:  limit.Burst,
+			Reset:      0,
+			RetryAfter: 0,
+		}
+	}
+
+	if bucket.Updated.IsZero() {
+		bucket.Tokens = burst
+		bucket.Updated = now
+	} else if elapsed := now.Sub(bucket.Updated); elapsed > 0 {
+		refilled := elapsed.Seconds() * float64(limit.Rate)
+		bucket.Tokens = min(burst, bucket.Tokens+refilled)
+		bucket.Updated = now
+	}
+
+	allowed := bucket.Tokens >= 1
+	if allowed {
+		bucket.Tokens--
+	}
+
+	return RateLimitResult{
+		Allowed:    allowed,
+		Limit:      limit.Burst,
+		Remaining:  int(bucket.Tokens),
+		Reset:      tokenDuration(burst-bucket.Tokens, limit.Rate),
+		RetryAfter: tokenDuration(max(1-bucket.Tokens, 0), limit.Rate),
+	}
+}
+
+// tokenDuration returns the time needed to refill the provided amount of tokens.
+func tokenDuration(tokens float64, limit rate.Limit) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+
+	if limit <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Duration(tokens / float64(limit) * float64(time.Second))
+}
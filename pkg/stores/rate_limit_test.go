@@ -0,0 +1,44 @@
+package stores_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/XDoubleU/essentia/pkg/stores"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestTakeToken(t *testing.T) {
+	now := time.Now()
+	limit := stores.Limit{Rate: 2, Burst: 2}
+
+	//nolint:exhaustruct //a zero bucket is full
+	bucket := stores.TokenBucket{}
+
+	for range 2 {
+		assert.True(t, stores.TakeToken(&bucket, limit, now).Allowed)
+	}
+
+	result := stores.TakeToken(&bucket, limit, now)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	assert.Equal(t, time.Second, result.Reset)
+
+	result = stores.TakeToken(&bucket, limit, now.Add(500*time.Millisecond))
+	assert.True(t, result.Allowed)
+
+	result = stores.TakeToken(&bucket, limit, now.Add(time.Hour))
+	assert.Equal(t, 1, result.Remaining)
+
+	// an earlier time doesn't refill the time after it again
+	result = stores.TakeToken(&bucket, limit, now)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, now.Add(time.Hour), bucket.Updated)
+
+	result = stores.TakeToken(&bucket, limit, now.Add(time.Hour))
+	assert.False(t, result.Allowed)
+
+	unlimited := stores.Limit{Rate: rate.Inf, Burst: 0}
+	assert.True(t, stores.TakeToken(&bucket, unlimited, now).Allowed)
+}
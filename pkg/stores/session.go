@@ -0,0 +1,29 @@
+package stores
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// SessionRecord is the state of a session persisted by a [SessionStore].
+type SessionRecord struct {
+	ID         string                     `json:"id"`
+	Values     map[string]json.RawMessage `json:"values"`
+	CreatedAt  time.Time                  `json:"createdAt"`
+	LastSeenAt time.Time                  `json:"lastSeenAt"`
+}
+
+// SessionStore persists the [SessionRecord]s of sessions. The token returned
+// by Save is stored in the session cookie and provided to Load and Delete,
+// for server-side stores this is the ID of the record.
+// Load returns nil when the session doesn't exist or has expired.
+type SessionStore interface {
+	Load(ctx context.Context, token string) (*SessionRecord, error)
+	Save(
+		ctx context.Context,
+		record SessionRecord,
+		expiresAt time.Time,
+	) (string, error)
+	Delete(ctx context.Context, token string) error
+}
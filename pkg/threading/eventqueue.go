@@ -4,6 +4,8 @@ import (
 	"context"
 	"log/slog"
 	"sync"
+
+	"github.com/XDoubleU/essentia/pkg/tracing"
 )
 
 // Subscriber describes the interface of subscribers of new events.
@@ -15,9 +17,11 @@ type Subscriber interface {
 // EventQueue is used to divide [Subscriber]s between [Worker]s.
 // This prevents one [Worker] of being very busy.
 type EventQueue struct {
-	workerPool    WorkerPool
+	workerPool    *WorkerPool
 	subscribers   []Subscriber
 	subscribersMu *sync.RWMutex
+	stop          chan struct{}
+	stopOnce      *sync.Once
 }
 
 // NewEventQueue creates a new [EventQueue].
@@ -27,20 +31,39 @@ func NewEventQueue(
 	channelBufferSize int,
 ) *EventQueue {
 	pool := &EventQueue{
-		workerPool:    *NewWorkerPool(logger, maxWorkers, channelBufferSize),
+		workerPool:    NewWorkerPool(logger, maxWorkers, channelBufferSize),
 		subscribers:   []Subscriber{},
 		subscribersMu: &sync.RWMutex{},
+		stop:          make(chan struct{}),
+		stopOnce:      &sync.Once{},
 	}
 
 	return pool
 }
 
 // EnqueueEvent puts an event on the [Worker] channels.
+// Events are dropped once the [EventQueue] has been stopped,
+// also when waiting for room on a full queue.
 func (q *EventQueue) EnqueueEvent(event any) {
-	q.workerPool.EnqueueWork(func(ctx context.Context, logger *slog.Logger) error {
-		q.processEvent(ctx, logger, event)
-		return nil
-	})
+	work := queuedWork{
+		doWork: func(ctx context.Context, logger *slog.Logger) error {
+			q.processEvent(ctx, logger, event)
+			return nil
+		},
+		parent: tracing.SpanContext{},
+	}
+
+	// check first, as select picks randomly when both cases are ready
+	select {
+	case <-q.stop:
+		return
+	default:
+	}
+
+	select {
+	case <-q.stop:
+	case q.workerPool.queue <- work:
+	}
 }
 
 // AddSubscriber adds a [Subscriber] to the [EventQueue].
@@ -56,25 +79,44 @@ func (q *EventQueue) RemoveSubscriber(sub Subscriber) {
 	q.subscribersMu.Lock()
 	defer q.subscribersMu.Unlock()
 
-	var i int
-	for i = range q.subscribers {
+	for i := range q.subscribers {
 		if q.subscribers[i].ID() != sub.ID() {
 			continue
 		}
-		break
-	}
 
-	// delete subscriber
-	q.subscribers[i] = q.subscribers[len(q.subscribers)-1]
-	q.subscribers = q.subscribers[:len(q.subscribers)-1]
+		// delete subscriber
+		q.subscribers[i] = q.subscribers[len(q.subscribers)-1]
+		q.subscribers = q.subscribers[:len(q.subscribers)-1]
+		return
+	}
 }
 
-func (q *EventQueue) processEvent(_ context.Context, _ *slog.Logger, event any) {
+// Subscribers returns the [Subscriber]s of the [EventQueue].
+func (q *EventQueue) Subscribers() []Subscriber {
 	q.subscribersMu.RLock()
+	defer q.subscribersMu.RUnlock()
 
-	for _, sub := range q.subscribers {
+	subscribers := make([]Subscriber, len(q.subscribers))
+	copy(subscribers, q.subscribers)
+	return subscribers
+}
+
+// Stop removes all [Subscriber]s and stops the [Worker]s of the [EventQueue].
+func (q *EventQueue) Stop() {
+	q.stopOnce.Do(func() {
+		close(q.stop)
+	})
+
+	q.subscribersMu.Lock()
+	q.subscribers = []Subscriber{}
+	q.subscribersMu.Unlock()
+
+	q.workerPool.Stop()
+}
+
+func (q *EventQueue) processEvent(_ context.Context, _ *slog.Logger, event any) {
+	// copy subscribers as callbacks are allowed to remove themselves
+	for _, sub := range q.Subscribers() {
 		sub.OnEventCallback(event)
 	}
-
-	q.subscribersMu.RUnlock()
 }
@@ -67,3 +67,65 @@ func TestBasic(t *testing.T) {
 
 	wp.RemoveSubscriber(tSub)
 }
+
+func TestEventQueueStop(t *testing.T) {
+	logger := logging.NewNopLogger()
+
+	wp := threading.NewEventQueue(logger, 1, 1)
+
+	tSub := NewTestSubscriber()
+	wp.AddSubscriber(tSub)
+	assert.Len(t, wp.Subscribers(), 1)
+
+	wp.Stop()
+	assert.Len(t, wp.Subscribers(), 0)
+
+	// stopped queues shouldn't block when enqueueing events
+	for range 5 {
+		wp.EnqueueEvent("Hello, World!")
+	}
+	time.Sleep(sleep)
+
+	assert.Equal(t, "", tSub.Output())
+}
+
+type blockingSubscriber struct {
+	release chan struct{}
+}
+
+func (sub blockingSubscriber) ID() string {
+	return "blocking"
+}
+
+func (sub blockingSubscriber) OnEventCallback(_ any) {
+	<-sub.release
+}
+
+func TestEventQueueStopWhileEnqueueing(t *testing.T) {
+	logger := logging.NewNopLogger()
+
+	wp := threading.NewEventQueue(logger, 1, 1)
+
+	sub := blockingSubscriber{release: make(chan struct{})}
+	defer close(sub.release)
+	wp.AddSubscriber(sub)
+
+	// the first event blocks the worker and the second fills the queue
+	wp.EnqueueEvent("first")
+	wp.EnqueueEvent("second")
+
+	done := make(chan struct{})
+	go func() {
+		wp.EnqueueEvent("third")
+		close(done)
+	}()
+
+	time.Sleep(sleep)
+	wp.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("EnqueueEvent kept blocking after Stop")
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"log/slog"
 	"sync"
 	"time"
+
+	"github.com/XDoubleU/essentia/pkg/metrics"
+	"github.com/XDoubleU/essentia/pkg/tracing"
 )
 
 // CallbackFunc describes the interface for a func
@@ -15,12 +18,14 @@ type CallbackFunc = func(id string, isRunning bool, lastRunTime *time.Time)
 
 // JobQueue is a queue of Jobs which will be executed by the workerpool.
 type JobQueue struct {
-	workerPool      WorkerPool
+	workerPool      *WorkerPool
 	logger          *slog.Logger
 	recurringJobs   map[string]*jobContainer
 	jobsMu          sync.RWMutex
 	schedulerActive bool
 	schedulerMu     sync.RWMutex
+	runs            *metrics.Counter
+	durations       *metrics.Histogram
 }
 
 // Job describes the interface for a job executable by the jobqueue.
@@ -42,17 +47,38 @@ type jobContainer struct {
 // NewJobQueue creates a new jobqueue.
 func NewJobQueue(logger *slog.Logger, amountWorkers int, size int) *JobQueue {
 	jobQueue := &JobQueue{
-		workerPool:      *NewWorkerPool(logger, amountWorkers, size),
+		workerPool:      NewWorkerPool(logger, amountWorkers, size),
 		logger:          logger,
 		recurringJobs:   make(map[string]*jobContainer),
 		schedulerActive: false,
 		jobsMu:          sync.RWMutex{},
 		schedulerMu:     sync.RWMutex{},
+		runs:            nil,
+		durations:       nil,
 	}
 
 	return jobQueue
 }
 
+// RegisterMetrics records the amount of runs of jobs by result and their
+// duration in registry, next to the metrics of the [WorkerPool] of a
+// [JobQueue], labeled as job_queue. This should be done before adding jobs.
+func (q *JobQueue) RegisterMetrics(registry *metrics.Registry) {
+	q.workerPool.RegisterMetrics(registry, "job_queue")
+
+	q.runs = registry.Counter(
+		"job_runs_total",
+		"Total amount of runs of jobs by result.",
+		"job", "result",
+	)
+	q.durations = registry.Histogram(
+		"job_duration_seconds",
+		"Duration of runs of jobs in seconds.",
+		nil,
+		"job",
+	)
+}
+
 // Clear clears the JobQueue completely.
 func (q *JobQueue) Clear() {
 	if q.isSchedulerActive() {
@@ -149,7 +175,30 @@ func (q *JobQueue) push(jobContainer *jobContainer) {
 	jobContainer.isPushed = true
 	jobContainer.mu.Unlock()
 
-	q.workerPool.EnqueueWork(jobContainer.run)
+	q.workerPool.EnqueueWork(func(ctx context.Context, logger *slog.Logger) error {
+		if span := tracing.SpanFromContext(ctx); span != nil {
+			span.SetAttribute("job.id", jobContainer.job.ID())
+		}
+
+		start := time.Now()
+		err := jobContainer.run(ctx, logger)
+		q.record(jobContainer.job.ID(), time.Since(start), err)
+		return err
+	})
+}
+
+func (q *JobQueue) record(id string, duration time.Duration, err error) {
+	if q.runs == nil {
+		return
+	}
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	q.runs.Inc(id, result)
+	q.durations.Observe(duration.Seconds(), id)
 }
 
 func (q *JobQueue) startScheduler() {
@@ -2,12 +2,16 @@ package threading_test
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/metrics"
 	"github.com/XDoubleU/essentia/pkg/threading"
 	"github.com/stretchr/testify/assert"
 )
@@ -132,3 +136,44 @@ func TestJobQueueForce(t *testing.T) {
 	assert.Equal(t, []bool{true, false, true, false}, states)
 	statesMu.Unlock()
 }
+
+type FailingJob struct {
+}
+
+func (j FailingJob) ID() string {
+	return "failing"
+}
+
+func (j FailingJob) Run(_ context.Context, _ *slog.Logger) error {
+	return errors.New("failed")
+}
+
+func (j FailingJob) RunEvery() time.Duration {
+	return time.Hour
+}
+
+func scrapeMetrics(registry *metrics.Registry) string {
+	rr := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rr.Body.String()
+}
+
+func TestJobQueueMetrics(t *testing.T) {
+	jobQueue := threading.NewJobQueue(logging.NewNopLogger(), 1, 1)
+	registry := metrics.NewRegistry()
+	jobQueue.RegisterMetrics(registry)
+
+	callback := func(_ string, _ bool, _ *time.Time) {}
+	assert.Nil(t, jobQueue.AddJob(TestJob{}, callback))
+	assert.Nil(t, jobQueue.AddJob(FailingJob{}, callback))
+
+	time.Sleep(400 * time.Millisecond)
+
+	output := scrapeMetrics(registry)
+	assert.Contains(t, output, `job_runs_total{job="test",result="success"} 1`)
+	assert.Contains(t, output, `job_runs_total{job="failing",result="error"} 1`)
+	assert.Contains(t, output, `job_duration_seconds_count{job="test"} 1`)
+	assert.Contains(t, output, `worker_pool_queue_length{pool="job_queue"} 0`)
+
+	jobQueue.Clear()
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"log/slog"
 	"sync"
+
+	"github.com/XDoubleU/essentia/pkg/tracing"
 )
 
 // Worker is used to handle work of the [WorkerPool].
@@ -59,17 +61,21 @@ func (worker *Worker) Run(ctx context.Context, logger *slog.Logger) error {
 	worker.active = true
 	worker.activeMu.Unlock()
 
+	stop := worker.pool.stopChannel()
+
 	for worker.Active() {
-		doWork := <-worker.pool.queue
+		var work queuedWork
+		select {
+		case <-stop:
+			return nil
+		case work = <-worker.pool.queue:
+		}
 
 		worker.isDoingWorkMu.Lock()
 		worker.isDoingWork = true
 		worker.isDoingWorkMu.Unlock()
 
-		err := doWork(ctx, logger)
-		if err != nil {
-			logger.Error(err.Error())
-		}
+		worker.doWork(ctx, logger, work)
 
 		worker.isDoingWorkMu.Lock()
 		worker.isDoingWork = false
@@ -78,3 +84,32 @@ func (worker *Worker) Run(ctx context.Context, logger *slog.Logger) error {
 
 	return nil
 }
+
+// doWork does work, within a [tracing.Span] continuing the trace
+// of the request which enqueued it. Work enqueued outside
+// of a trace isn't traced, to not start a trace for every item.
+func (worker *Worker) doWork(
+	ctx context.Context,
+	logger *slog.Logger,
+	work queuedWork,
+) {
+	var span tracing.Span
+	if work.parent.IsValid() {
+		ctx = tracing.ContextWithRemoteSpanContext(ctx, work.parent)
+		ctx, span = tracing.Start(
+			ctx,
+			"worker.job",
+			tracing.WithKind(tracing.SpanKindConsumer),
+			tracing.WithAttribute("worker.id", worker.id),
+		)
+		defer span.End()
+	}
+
+	err := work.doWork(ctx, logger)
+	if err != nil {
+		if span != nil {
+			span.RecordError(err)
+		}
+		logger.Error(err.Error())
+	}
+}
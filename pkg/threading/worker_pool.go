@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+	"github.com/XDoubleU/essentia/pkg/metrics"
 	"github.com/XDoubleU/essentia/pkg/sentry"
+	"github.com/XDoubleU/essentia/pkg/tracing"
 )
 
 // DoWork describes the interface for work executed by the workers.
@@ -17,7 +21,16 @@ type DoWork = func(ctx context.Context, logger *slog.Logger) error
 type WorkerPool struct {
 	logger  *slog.Logger
 	workers []Worker
-	queue   chan DoWork
+	queue   chan queuedWork
+	stop    chan struct{}
+	stopMu  *sync.RWMutex
+}
+
+// queuedWork is work on the queue of a [WorkerPool] with
+// the trace of the request which enqueued it, if any.
+type queuedWork struct {
+	doWork DoWork
+	parent tracing.SpanContext
 }
 
 // NewWorkerPool creates a new [WorkerPool].
@@ -29,7 +42,9 @@ func NewWorkerPool(
 	pool := &WorkerPool{
 		logger:  logger,
 		workers: make([]Worker, amountWorkers),
-		queue:   make(chan DoWork, queueSize),
+		queue:   make(chan queuedWork, queueSize),
+		stop:    make(chan struct{}),
+		stopMu:  &sync.RWMutex{},
 	}
 
 	pool.createWorkers(amountWorkers)
@@ -61,6 +76,14 @@ func (pool *WorkerPool) IsDoingWork() bool {
 
 // Start starts [Worker]s of a [WorkerPool] if they weren't active yet.
 func (pool *WorkerPool) Start() {
+	pool.stopMu.Lock()
+	select {
+	case <-pool.stop:
+		pool.stop = make(chan struct{})
+	default:
+	}
+	pool.stopMu.Unlock()
+
 	for i := range pool.workers {
 		go sentry.GoRoutineWrapper(
 			context.Background(),
@@ -71,9 +94,71 @@ func (pool *WorkerPool) Start() {
 	}
 }
 
-// EnqueueWork puts an work on the queue.
+// EnqueueWork puts an work on the queue, which isn't traced.
 func (pool *WorkerPool) EnqueueWork(doWork DoWork) {
-	pool.queue <- doWork
+	pool.queue <- queuedWork{doWork: doWork, parent: tracing.SpanContext{}}
+}
+
+// EnqueueWorkWithContext puts work on the queue, keeping the ID of
+// the request and the trace stored in ctx, for example when the work is
+// started by a request. The work is only traced when ctx contains a trace.
+// The work isn't cancelled when ctx is cancelled.
+func (pool *WorkerPool) EnqueueWorkWithContext(ctx context.Context, doWork DoWork) {
+	work := queuedWork{
+		doWork: doWork,
+		parent: tracing.SpanContextFromContext(ctx),
+	}
+
+	if id := contexttools.RequestID(ctx); id != "" {
+		work.doWork = func(ctx context.Context, logger *slog.Logger) error {
+			ctx = contexttools.WithRequestID(ctx, id)
+			logger = logger.With(slog.String("request_id", id))
+			return doWork(contexttools.WithLogger(ctx, logger), logger)
+		}
+	}
+
+	pool.queue <- work
+}
+
+// QueueLength returns the amount of work waiting on the queue.
+func (pool *WorkerPool) QueueLength() int {
+	return len(pool.queue)
+}
+
+// BusyWorkers returns the amount of [Worker]s doing work.
+func (pool *WorkerPool) BusyWorkers() int {
+	busy := 0
+	for i := range pool.workers {
+		if pool.workers[i].IsDoingWork() {
+			busy++
+		}
+	}
+	return busy
+}
+
+// RegisterMetrics exposes the queue length and the amount of
+// busy [Worker]s of a [WorkerPool] in registry, labeled by name.
+func (pool *WorkerPool) RegisterMetrics(registry *metrics.Registry, name string) {
+	registry.GaugeFunc(
+		"worker_pool_queue_length",
+		"Amount of work waiting on the queue of a worker pool.",
+		[]string{"pool"},
+		func() []metrics.Sample {
+			return []metrics.Sample{
+				{LabelValues: []string{name}, Value: float64(pool.QueueLength())},
+			}
+		},
+	)
+	registry.GaugeFunc(
+		"worker_pool_busy_workers",
+		"Amount of workers of a worker pool doing work.",
+		[]string{"pool"},
+		func() []metrics.Sample {
+			return []metrics.Sample{
+				{LabelValues: []string{name}, Value: float64(pool.BusyWorkers())},
+			}
+		},
+	)
 }
 
 // IsWorkRemaining checks if there is still work on the queue.
@@ -90,10 +175,28 @@ func (pool *WorkerPool) WaitUntilDone() {
 }
 
 // Stop stops all workers.
+// Work that is being done is finished first,
+// idle workers stop immediately.
 func (pool *WorkerPool) Stop() {
 	for i := range pool.workers {
 		pool.workers[i].Stop()
 	}
+
+	pool.stopMu.Lock()
+	defer pool.stopMu.Unlock()
+
+	select {
+	case <-pool.stop:
+	default:
+		close(pool.stop)
+	}
+}
+
+func (pool *WorkerPool) stopChannel() chan struct{} {
+	pool.stopMu.RLock()
+	defer pool.stopMu.RUnlock()
+
+	return pool.stop
 }
 
 func (pool *WorkerPool) createWorkers(amountWorkers int) {
@@ -2,13 +2,21 @@ package threading_test
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"testing"
 	"time"
 
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
 	"github.com/XDoubleU/essentia/pkg/logging"
+	"github.com/XDoubleU/essentia/pkg/metrics"
 	"github.com/XDoubleU/essentia/pkg/threading"
+	"github.com/XDoubleU/essentia/pkg/tracing"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func doWork(_ context.Context, _ *slog.Logger) error {
@@ -25,3 +33,87 @@ func TestBasicWorkerPool(t *testing.T) {
 	workerpool.WaitUntilDone()
 	assert.False(t, workerpool.IsDoingWork())
 }
+
+func TestEnqueueWorkWithContext(t *testing.T) {
+	workerpool := threading.NewWorkerPool(logging.NewNopLogger(), 1, 1)
+
+	ctx, cancel := context.WithCancel(
+		contexttools.WithRequestID(context.Background(), "id"),
+	)
+	cancel()
+
+	requestID := make(chan string, 1)
+	workerpool.EnqueueWorkWithContext(
+		ctx,
+		func(ctx context.Context, _ *slog.Logger) error {
+			requestID <- contexttools.RequestID(ctx)
+			return ctx.Err()
+		},
+	)
+
+	assert.Equal(t, "id", <-requestID)
+	workerpool.WaitUntilDone()
+}
+
+func TestWorkerPoolMetrics(t *testing.T) {
+	workerpool := threading.NewWorkerPool(logging.NewNopLogger(), 1, 2)
+	registry := metrics.NewRegistry()
+	workerpool.RegisterMetrics(registry, "test")
+
+	release := make(chan struct{})
+	blockingWork := func(_ context.Context, _ *slog.Logger) error {
+		<-release
+		return nil
+	}
+
+	workerpool.EnqueueWork(blockingWork)
+	workerpool.EnqueueWork(blockingWork)
+
+	assert.Eventually(t, func() bool {
+		return workerpool.BusyWorkers() == 1 && workerpool.QueueLength() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	output := scrapeMetrics(registry)
+	assert.Contains(t, output, `worker_pool_queue_length{pool="test"} 1`)
+	assert.Contains(t, output, `worker_pool_busy_workers{pool="test"} 1`)
+
+	close(release)
+	workerpool.WaitUntilDone()
+}
+
+func TestWorkerPoolTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer := tracing.NewOTelTracer(provider)
+	tracing.SetTracer(tracer)
+	defer tracing.SetTracer(tracing.NewSentryTracer())
+
+	workerpool := threading.NewWorkerPool(logging.NewNopLogger(), 1, 1)
+
+	ctx, request := tracer.Start(context.Background(), "request")
+	request.End()
+
+	workerpool.EnqueueWorkWithContext(
+		ctx,
+		func(_ context.Context, _ *slog.Logger) error {
+			return errors.New("failed")
+		},
+	)
+	workerpool.EnqueueWorkWithContext(
+		context.Background(),
+		func(_ context.Context, _ *slog.Logger) error {
+			return errors.New("untraced")
+		},
+	)
+	workerpool.WaitUntilDone()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "worker.job", spans[1].Name)
+	assert.Equal(
+		t,
+		request.SpanContext().SpanID.String(),
+		spans[1].Parent.SpanID().String(),
+	)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+}
@@ -0,0 +1,6 @@
+// Package tracing provides a tracing abstraction with a [SentryTracer]
+// and an [OTelTracer] backend, which adapts the OpenTelemetry API.
+// Traces are propagated using the W3C traceparent header, or using the
+// propagator of an [OTelTracer]. Spans are started using the [Tracer]
+// set by [SetTracer], which is a [SentryTracer] by default.
+package tracing
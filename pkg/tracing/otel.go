@@ -0,0 +1,208 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const otelInstrumentationName = "github.com/XDoubleU/essentia/pkg/tracing"
+
+// OTelOption configures an [OTelTracer].
+type OTelOption func(tracer *OTelTracer)
+
+// WithPropagator sets the [propagation.TextMapPropagator] used by [Inject]
+// and [Extract], which is [propagation.TraceContext] by default.
+// Use otel.GetTextMapPropagator() to use the global propagator.
+func WithPropagator(propagator propagation.TextMapPropagator) OTelOption {
+	return func(tracer *OTelTracer) {
+		tracer.propagator = propagator
+	}
+}
+
+// OTelTracer is a [Tracer] starting spans using an OpenTelemetry
+// [trace.TracerProvider], such as the TracerProvider of the OpenTelemetry SDK.
+// Sampling, processing and exporting spans are configured on that provider.
+// Spans started by OpenTelemetry instrumentation are used as parent and
+// the other way around, as both are stored in the context.
+type OTelTracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewOTelTracer creates a new [OTelTracer] using provider,
+// for which otel.GetTracerProvider() can be used.
+func NewOTelTracer(
+	provider trace.TracerProvider,
+	options ...OTelOption,
+) *OTelTracer {
+	tracer := &OTelTracer{
+		tracer:     provider.Tracer(otelInstrumentationName),
+		propagator: propagation.TraceContext{},
+	}
+
+	for _, option := range options {
+		option(tracer)
+	}
+
+	return tracer
+}
+
+// Start starts a [Span] which is a child of the span stored in ctx,
+// either by this package or by OpenTelemetry.
+func (t *OTelTracer) Start(
+	ctx context.Context,
+	name string,
+	options ...SpanOption,
+) (context.Context, Span) {
+	config := NewSpanConfig(name, options...)
+
+	attributes := make([]attribute.KeyValue, 0, len(config.Attributes))
+	for key, value := range config.Attributes {
+		attributes = append(attributes, otelAttribute(key, value))
+	}
+
+	ctx, span := t.tracer.Start(
+		otelContext(ctx),
+		name,
+		trace.WithSpanKind(otelSpanKind(config.Kind)),
+		trace.WithAttributes(attributes...),
+	)
+
+	result := otelSpan{span: span}
+	return ContextWithSpan(ctx, result), result
+}
+
+// Inject sets the headers of the propagator of an [OTelTracer]
+// to the span stored in the context, if any.
+func (t *OTelTracer) Inject(ctx context.Context, header http.Header) {
+	t.propagator.Inject(otelContext(ctx), propagation.HeaderCarrier(header))
+}
+
+// Extract returns a context continuing the trace
+// extracted by the propagator of an [OTelTracer].
+func (t *OTelTracer) Extract(
+	ctx context.Context,
+	header http.Header,
+) context.Context {
+	ctx = t.propagator.Extract(ctx, propagation.HeaderCarrier(header))
+
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() || !sc.IsRemote() {
+		return ctx
+	}
+
+	return ContextWithRemoteSpanContext(ctx, fromOTelSpanContext(sc))
+}
+
+// otelContext makes a span stored in ctx which wasn't started by OpenTelemetry,
+// such as a remote span, available to OpenTelemetry as remote parent.
+func otelContext(ctx context.Context) context.Context {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return ctx
+	}
+
+	// spans of an OTelTracer are stored in the context by OpenTelemetry as well
+	if _, ok := span.(otelSpan); ok {
+		return ctx
+	}
+
+	sc := span.SpanContext()
+	if !sc.IsValid() {
+		return ctx
+	}
+
+	var flags trace.TraceFlags
+	if sc.Sampled {
+		flags = trace.FlagsSampled
+	}
+
+	//nolint:exhaustruct //trace state isn't propagated by this package
+	return trace.ContextWithRemoteSpanContext(
+		ctx,
+		trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID(sc.TraceID),
+			SpanID:     trace.SpanID(sc.SpanID),
+			TraceFlags: flags,
+			Remote:     true,
+		}),
+	)
+}
+
+func fromOTelSpanContext(sc trace.SpanContext) SpanContext {
+	return SpanContext{
+		TraceID: TraceID(sc.TraceID()),
+		SpanID:  SpanID(sc.SpanID()),
+		Sampled: sc.IsSampled(),
+	}
+}
+
+func otelSpanKind(kind SpanKind) trace.SpanKind {
+	switch kind {
+	case SpanKindServer:
+		return trace.SpanKindServer
+	case SpanKindClient:
+		return trace.SpanKindClient
+	case SpanKindProducer:
+		return trace.SpanKindProducer
+	case SpanKindConsumer:
+		return trace.SpanKindConsumer
+	case SpanKindInternal:
+		return trace.SpanKindInternal
+	default:
+		return trace.SpanKindInternal
+	}
+}
+
+// otelAttribute converts an attribute to its OpenTelemetry type,
+// formatting values of unsupported types as string.
+func otelAttribute(key string, value any) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case []string:
+		return attribute.StringSlice(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
+
+// otelSpan is a [Span] wrapping an OpenTelemetry [trace.Span].
+type otelSpan struct {
+	span trace.Span
+}
+
+// SpanContext returns the [SpanContext] of a span.
+func (s otelSpan) SpanContext() SpanContext {
+	return fromOTelSpanContext(s.span.SpanContext())
+}
+
+// SetAttribute sets an attribute of a span.
+func (s otelSpan) SetAttribute(key string, value any) {
+	s.span.SetAttributes(otelAttribute(key, value))
+}
+
+// RecordError records err as event of a span and marks it as failed.
+func (s otelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End finishes a span.
+func (s otelSpan) End() {
+	s.span.End()
+}
@@ -0,0 +1,152 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/XDoubleU/essentia/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newOTelTracer(
+	t *testing.T,
+) (*tracing.OTelTracer, *sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+	})
+
+	return tracing.NewOTelTracer(provider), provider, exporter
+}
+
+func TestOTelTracer(t *testing.T) {
+	tracer, _, exporter := newOTelTracer(t)
+
+	ctx, parent := tracer.Start(
+		context.Background(),
+		"parent",
+		tracing.WithKind(tracing.SpanKindServer),
+		tracing.WithAttribute("key", "value"),
+	)
+	_, child := tracer.Start(ctx, "child")
+	child.SetAttribute("amount", 1)
+	child.RecordError(errors.New("failed"))
+	child.End()
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, trace.SpanKindInternal, spans[0].SpanKind)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "failed", spans[0].Status.Description)
+	assert.Contains(t, spans[0].Attributes, attribute.Int("amount", 1))
+	assert.Equal(
+		t,
+		parent.SpanContext().SpanID.String(),
+		spans[0].Parent.SpanID().String(),
+	)
+	assert.Equal(
+		t,
+		parent.SpanContext().TraceID.String(),
+		spans[0].SpanContext.TraceID().String(),
+	)
+
+	assert.Equal(t, "parent", spans[1].Name)
+	assert.Equal(t, trace.SpanKindServer, spans[1].SpanKind)
+	assert.Contains(t, spans[1].Attributes, attribute.String("key", "value"))
+	assert.False(t, spans[1].Parent.IsValid())
+	assert.True(t, parent.SpanContext().Sampled)
+}
+
+func TestOTelTracerRemoteParent(t *testing.T) {
+	tracer, _, exporter := newOTelTracer(t)
+
+	remote, err := tracing.ParseTraceparent(traceparent)
+	require.Nil(t, err)
+
+	ctx := tracing.ContextWithRemoteSpanContext(context.Background(), remote)
+	_, span := tracer.Start(ctx, "span")
+	span.End()
+
+	remote.Sampled = false
+	ctx = tracing.ContextWithRemoteSpanContext(context.Background(), remote)
+	_, span = tracer.Start(ctx, "not sampled")
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, remote.TraceID.String(), spans[0].SpanContext.TraceID().String())
+	assert.Equal(t, remote.SpanID.String(), spans[0].Parent.SpanID().String())
+	assert.True(t, spans[0].Parent.IsRemote())
+}
+
+func TestOTelTracerInterop(t *testing.T) {
+	tracer, provider, exporter := newOTelTracer(t)
+	instrumentation := provider.Tracer("instrumentation")
+
+	ctx, outer := instrumentation.Start(context.Background(), "outer")
+	ctx, span := tracer.Start(ctx, "span")
+	_, inner := instrumentation.Start(ctx, "inner")
+	inner.End()
+	span.End()
+	outer.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "inner", spans[0].Name)
+	assert.Equal(t, span.SpanContext().SpanID.String(), spans[0].Parent.SpanID().String())
+	assert.Equal(t, "span", spans[1].Name)
+	assert.Equal(t, outer.SpanContext().SpanID(), spans[1].Parent.SpanID())
+}
+
+func TestOTelTracerPropagation(t *testing.T) {
+	tracer, _, _ := newOTelTracer(t)
+
+	tracing.SetTracer(tracer)
+	defer tracing.SetTracer(tracing.NewSentryTracer())
+
+	header := http.Header{}
+	header.Set(tracing.TraceparentHeader, traceparent)
+
+	ctx := tracing.Extract(context.Background(), header)
+	remote := tracing.SpanContextFromContext(ctx)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", remote.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", remote.SpanID.String())
+
+	ctx, span := tracing.Start(ctx, "span")
+	defer span.End()
+
+	header = http.Header{}
+	tracing.Inject(ctx, header)
+	assert.Equal(
+		t,
+		span.SpanContext().Traceparent(),
+		header.Get(tracing.TraceparentHeader),
+	)
+}
+
+func TestGlobalTracer(t *testing.T) {
+	tracer, _, exporter := newOTelTracer(t)
+
+	tracing.SetTracer(tracer)
+	defer tracing.SetTracer(tracing.NewSentryTracer())
+
+	ctx, span := tracing.Start(context.Background(), "span")
+	assert.Equal(t, span, tracing.SpanFromContext(ctx))
+	span.End()
+
+	assert.Len(t, exporter.GetSpans(), 1)
+}
@@ -0,0 +1,139 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// TraceparentHeader is the W3C header used to propagate traces.
+const TraceparentHeader = "traceparent"
+
+const (
+	traceparentVersion = "00"
+	sampledFlag        = 0x01
+)
+
+// ErrInvalidTraceparent is returned when a traceparent header is malformed.
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// SpanID identifies a [Span] within a trace.
+type SpanID [8]byte
+
+// String returns the hex encoding of a [TraceID].
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// String returns the hex encoding of a [SpanID].
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanContext identifies a [Span] across services.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid checks if a [SpanContext] has a trace and span ID.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent formats a [SpanContext] as traceparent header.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	return traceparentVersion + "-" + sc.TraceID.String() + "-" +
+		sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parses a traceparent header into a [SpanContext].
+func ParseTraceparent(traceparent string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+
+	//nolint:mnd //version, trace ID, span ID and flags
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		(parts[0] == traceparentVersion && len(parts) != 4) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	var sc SpanContext
+
+	if !decodeHex(parts[1], sc.TraceID[:]) || !decodeHex(parts[2], sc.SpanID[:]) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	var flags [1]byte
+	if !decodeHex(parts[3], flags[:]) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	sc.Sampled = flags[0]&sampledFlag != 0
+	return sc, nil
+}
+
+// decodeHex decodes lowercase hex into dst, which has to be filled exactly.
+func decodeHex(value string, dst []byte) bool {
+	if len(value) != hex.EncodedLen(len(dst)) || strings.ToLower(value) != value {
+		return false
+	}
+
+	_, err := hex.Decode(dst, []byte(value))
+	return err == nil
+}
+
+// Propagator is implemented by [Tracer]s propagating traces in their own way,
+// which is then used by [Inject] and [Extract] instead of traceparent headers.
+type Propagator interface {
+	Inject(ctx context.Context, header http.Header)
+	Extract(ctx context.Context, header http.Header) context.Context
+}
+
+// Inject sets the traceparent header to the [SpanContext]
+// of the [Span] stored in the context, if any. When the [Tracer] set
+// by [SetTracer] is a [Propagator], it sets the headers instead.
+func Inject(ctx context.Context, header http.Header) {
+	if propagator, ok := GetTracer().(Propagator); ok {
+		propagator.Inject(ctx, header)
+		return
+	}
+
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	header.Set(TraceparentHeader, sc.Traceparent())
+}
+
+// Extract returns a context continuing the trace of a valid traceparent
+// header using [ContextWithRemoteSpanContext]. Otherwise ctx is returned.
+// When the [Tracer] set by [SetTracer] is a [Propagator],
+// it extracts the trace instead.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	if propagator, ok := GetTracer().(Propagator); ok {
+		return propagator.Extract(ctx, header)
+	}
+
+	sc, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
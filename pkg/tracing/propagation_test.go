@@ -0,0 +1,68 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/XDoubleU/essentia/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := tracing.ParseTraceparent(traceparent)
+	require.Nil(t, err)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, traceparent, sc.Traceparent())
+
+	sc, err = tracing.ParseTraceparent(
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future",
+	)
+	require.Nil(t, err)
+	assert.False(t, sc.Sampled)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz",
+	} {
+		_, err = tracing.ParseTraceparent(invalid)
+		assert.ErrorIs(t, err, tracing.ErrInvalidTraceparent, invalid)
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	header := http.Header{}
+	header.Set(tracing.TraceparentHeader, traceparent)
+
+	ctx := tracing.Extract(context.Background(), header)
+	assert.Equal(
+		t,
+		"4bf92f3577b34da6a3ce929d0e0e4736",
+		tracing.SpanContextFromContext(ctx).TraceID.String(),
+	)
+
+	outgoing := http.Header{}
+	tracing.Inject(ctx, outgoing)
+	assert.Equal(t, traceparent, outgoing.Get(tracing.TraceparentHeader))
+
+	outgoing = http.Header{}
+	tracing.Inject(context.Background(), outgoing)
+	assert.Empty(t, outgoing.Get(tracing.TraceparentHeader))
+
+	header.Set(tracing.TraceparentHeader, "invalid")
+	ctx = tracing.Extract(context.Background(), header)
+	assert.False(t, tracing.SpanContextFromContext(ctx).IsValid())
+}
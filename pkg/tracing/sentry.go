@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const statusCodeAttribute = "http.response.status_code"
+
+// SentryTracer is a [Tracer] starting [sentry.Span]s. Spans without a parent
+// Sentry span are started as transaction, which continue the remote trace
+// stored in the context, if any. Spans are sent by the [sentry.Hub]
+// of the context, as configured by the Sentry middleware.
+type SentryTracer struct {
+}
+
+// NewSentryTracer creates a new [SentryTracer].
+func NewSentryTracer() *SentryTracer {
+	return &SentryTracer{}
+}
+
+// Start starts a [sentry.Span] or transaction.
+func (t *SentryTracer) Start(
+	ctx context.Context,
+	name string,
+	options ...SpanOption,
+) (context.Context, Span) {
+	config := NewSpanConfig(name, options...)
+
+	var span *sentry.Span
+	if sentry.SpanFromContext(ctx) != nil {
+		spanOptions := []sentry.SpanOption{sentry.WithDescription(name)}
+
+		if transaction := sentry.TransactionFromContext(ctx); transaction != nil {
+			spanOptions = append(
+				spanOptions,
+				sentry.WithTransactionName(transaction.Name),
+			)
+		}
+
+		span = sentry.StartSpan(ctx, config.Operation, spanOptions...)
+	} else {
+		spanOptions := []sentry.SpanOption{sentry.WithOpName(config.Operation)}
+
+		if parent := SpanContextFromContext(ctx); parent.IsValid() {
+			spanOptions = append(
+				spanOptions,
+				sentry.ContinueFromTrace(sentryTrace(parent)),
+			)
+		}
+
+		span = sentry.StartTransaction(ctx, name, spanOptions...)
+	}
+
+	result := &sentrySpan{span: span}
+	for key, value := range config.Attributes {
+		result.SetAttribute(key, value)
+	}
+
+	return ContextWithSpan(span.Context(), result), result
+}
+
+// sentryTrace formats a [SpanContext] as sentry-trace header.
+func sentryTrace(sc SpanContext) string {
+	sampled := 0
+	if sc.Sampled {
+		sampled = 1
+	}
+
+	return fmt.Sprintf("%s-%s-%d", sc.TraceID, sc.SpanID, sampled)
+}
+
+type sentrySpan struct {
+	span *sentry.Span
+}
+
+// SpanContext returns the [SpanContext] of a [sentry.Span].
+func (s *sentrySpan) SpanContext() SpanContext {
+	return SpanContext{
+		TraceID: TraceID(s.span.TraceID),
+		SpanID:  SpanID(s.span.SpanID),
+		Sampled: s.span.Sampled.Bool(),
+	}
+}
+
+// SetAttribute sets data of a [sentry.Span]. The status of the span
+// is derived from the http.response.status_code attribute.
+func (s *sentrySpan) SetAttribute(key string, value any) {
+	s.span.SetData(key, value)
+
+	if status, ok := value.(int); ok && key == statusCodeAttribute {
+		s.span.Status = sentry.HTTPtoSpanStatus(status)
+	}
+}
+
+// RecordError marks a [sentry.Span] as failed.
+func (s *sentrySpan) RecordError(err error) {
+	s.span.Status = sentry.SpanStatusInternalError
+	s.span.SetData("error", err.Error())
+}
+
+// End finishes a [sentry.Span].
+func (s *sentrySpan) End() {
+	s.span.Finish()
+}
@@ -0,0 +1,64 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/XDoubleU/essentia/pkg/tracing"
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSentryTracer(t *testing.T) {
+	tracer := tracing.NewSentryTracer()
+
+	ctx, transaction := tracer.Start(
+		context.Background(),
+		"transaction",
+		tracing.WithOperation("http.server"),
+	)
+	assert.Equal(t, "transaction", sentry.TransactionFromContext(ctx).Name)
+	assert.Equal(t, "http.server", sentry.TransactionFromContext(ctx).Op)
+
+	childCtx, child := tracer.Start(
+		ctx,
+		"SELECT 1",
+		tracing.WithOperation("db.query"),
+		tracing.WithAttribute("db.system", "postgresql"),
+	)
+	sentrySpan := sentry.SpanFromContext(childCtx)
+	assert.Equal(t, "db.query", sentrySpan.Op)
+	assert.Equal(t, "SELECT 1", sentrySpan.Description)
+	assert.Equal(t, "postgresql", sentrySpan.Data["db.system"])
+	assert.Equal(
+		t,
+		transaction.SpanContext().TraceID,
+		child.SpanContext().TraceID,
+	)
+
+	child.RecordError(errors.New("failed"))
+	assert.Equal(t, sentry.SpanStatusInternalError, sentrySpan.Status)
+	child.End()
+
+	transaction.SetAttribute("http.response.status_code", 404)
+	assert.Equal(
+		t,
+		sentry.SpanStatusNotFound,
+		sentry.TransactionFromContext(ctx).Status,
+	)
+	transaction.End()
+}
+
+func TestSentryTracerRemoteParent(t *testing.T) {
+	remote, err := tracing.ParseTraceparent(traceparent)
+	require.Nil(t, err)
+
+	ctx := tracing.ContextWithRemoteSpanContext(context.Background(), remote)
+	_, span := tracing.NewSentryTracer().Start(ctx, "transaction")
+	defer span.End()
+
+	assert.Equal(t, remote.TraceID, span.SpanContext().TraceID)
+	assert.NotEqual(t, remote.SpanID, span.SpanContext().SpanID)
+}
@@ -0,0 +1,185 @@
+package tracing
+
+import (
+	"context"
+	"sync/atomic"
+
+	contexttools "github.com/XDoubleU/essentia/pkg/context"
+)
+
+const spanContextKey = contexttools.Key("span")
+
+// SpanKind describes the relationship of a [Span] to its parent and children.
+type SpanKind int
+
+const (
+	// SpanKindInternal is the kind of spans of internal operations.
+	SpanKindInternal SpanKind = iota + 1
+	// SpanKindServer is the kind of spans of handled requests.
+	SpanKindServer
+	// SpanKindClient is the kind of spans of sent requests,
+	// such as database queries.
+	SpanKindClient
+	// SpanKindProducer is the kind of spans of enqueued work.
+	SpanKindProducer
+	// SpanKindConsumer is the kind of spans of handled work or messages.
+	SpanKindConsumer
+)
+
+// Tracer starts [Span]s.
+type Tracer interface {
+	// Start starts a [Span] which is a child of the span stored in ctx,
+	// if any, and returns a context containing the new span.
+	Start(
+		ctx context.Context,
+		name string,
+		options ...SpanOption,
+	) (context.Context, Span)
+}
+
+// Span is an operation which is part of a trace.
+type Span interface {
+	SpanContext() SpanContext
+	SetAttribute(key string, value any)
+	RecordError(err error)
+	End()
+}
+
+// SpanOption configures a [Span] started by a [Tracer].
+type SpanOption func(config *SpanConfig)
+
+// SpanConfig is the configuration of a [Span], used by [Tracer]s.
+type SpanConfig struct {
+	Kind       SpanKind
+	Operation  string
+	Attributes map[string]any
+}
+
+// WithKind sets the [SpanKind] of a [Span], which is internal by default.
+func WithKind(kind SpanKind) SpanOption {
+	return func(config *SpanConfig) {
+		config.Kind = kind
+	}
+}
+
+// WithOperation sets the category of a [Span], such as db.query,
+// which is used as operation by Sentry and ignored by other backends.
+// By default the name of the span is used.
+func WithOperation(operation string) SpanOption {
+	return func(config *SpanConfig) {
+		config.Operation = operation
+	}
+}
+
+// WithAttribute sets an attribute of a [Span] when it's started.
+func WithAttribute(key string, value any) SpanOption {
+	return func(config *SpanConfig) {
+		config.Attributes[key] = value
+	}
+}
+
+// NewSpanConfig applies [SpanOption]s to the default [SpanConfig].
+func NewSpanConfig(name string, options ...SpanOption) SpanConfig {
+	config := SpanConfig{
+		Kind:       SpanKindInternal,
+		Operation:  name,
+		Attributes: make(map[string]any),
+	}
+
+	for _, option := range options {
+		option(&config)
+	}
+
+	return config
+}
+
+type tracerHolder struct {
+	tracer Tracer
+}
+
+//nolint:gochecknoglobals //the tracer is set once at startup
+var globalTracer atomic.Pointer[tracerHolder]
+
+// SetTracer sets the [Tracer] used by [Start].
+func SetTracer(tracer Tracer) {
+	globalTracer.Store(&tracerHolder{tracer: tracer})
+}
+
+// GetTracer returns the [Tracer] used by [Start],
+// which is a [SentryTracer] when none was set.
+func GetTracer() Tracer {
+	holder := globalTracer.Load()
+	if holder == nil {
+		return NewSentryTracer()
+	}
+
+	return holder.tracer
+}
+
+// Start starts a [Span] using the [Tracer] set by [SetTracer].
+func Start(
+	ctx context.Context,
+	name string,
+	options ...SpanOption,
+) (context.Context, Span) {
+	return GetTracer().Start(ctx, name, options...)
+}
+
+// ContextWithSpan returns a context containing span,
+// which will be the parent of spans started using this context.
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanContextKey, span)
+}
+
+// SpanFromContext returns the [Span] stored in the context or nil.
+func SpanFromContext(ctx context.Context) Span {
+	span := contexttools.GetValue[Span](ctx, spanContextKey)
+	if span == nil {
+		return nil
+	}
+
+	return *span
+}
+
+// SpanContextFromContext returns the [SpanContext] of
+// the [Span] stored in the context, if any.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return SpanContext{}
+	}
+
+	return span.SpanContext()
+}
+
+// ContextWithRemoteSpanContext returns a context containing a [Span] which
+// isn't recorded, continuing the trace described by spanContext. This is used
+// to continue traces of other services or of work started by a request.
+func ContextWithRemoteSpanContext(
+	ctx context.Context,
+	spanContext SpanContext,
+) context.Context {
+	return ContextWithSpan(ctx, remoteSpan{spanContext: spanContext})
+}
+
+// remoteSpan is a [Span] started by another service or goroutine.
+type remoteSpan struct {
+	spanContext SpanContext
+}
+
+// SpanContext returns the [SpanContext] of a remote span.
+func (s remoteSpan) SpanContext() SpanContext {
+	return s.spanContext
+}
+
+// SetAttribute does nothing, as remote spans aren't recorded.
+func (s remoteSpan) SetAttribute(_ string, _ any) {
+}
+
+// RecordError does nothing, as remote spans aren't recorded.
+func (s remoteSpan) RecordError(_ error) {
+}
+
+// End does nothing, as remote spans aren't recorded.
+func (s remoteSpan) End() {
+}